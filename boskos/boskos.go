@@ -23,9 +23,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/test-infra/boskos/common"
@@ -35,7 +37,7 @@ import (
 
 var (
 	configPath        = flag.String("config", "config.yaml", "Path to init resource file")
-	storagePath       = flag.String("storage", "", "Path to persistent volume to load the state")
+	storagePath       = flag.String("storage", "", "Path to a file with resources to add if they are not in the CRDs yet")
 	kubeClientOptions crds.KubernetesClientOptions
 )
 
@@ -80,6 +82,15 @@ func main() {
 		}
 	}()
 
+	// Clean up dirty resources of types with a lifecycle hook, and create
+	// and delete dynamic resources.
+	go func() {
+		for range time.Tick(time.Minute) {
+			r.RunHooks()
+			r.SyncDynamicResources()
+		}
+	}()
+
 	logrus.Info("Start Service")
 	logrus.WithError(boskos.ListenAndServe()).Fatal("ListenAndServe returned.")
 }
@@ -94,6 +105,7 @@ func NewBoskosHandler(r *ranch.Ranch) *http.ServeMux {
 	mux.Handle("/reset", handleReset(r))
 	mux.Handle("/update", handleUpdate(r))
 	mux.Handle("/metric", handleMetric(r))
+	mux.Handle("/metrics", promhttp.Handler())
 	return mux
 }
 
@@ -108,6 +120,8 @@ func ErrorToStatus(err error) int {
 		return http.StatusNotFound
 	case *ranch.StateNotMatch:
 		return http.StatusConflict
+	case *ranch.QuotaExceeded:
+		return http.StatusTooManyRequests
 	}
 }
 
@@ -125,6 +139,8 @@ func handleDefault(r *ranch.Ranch) http.HandlerFunc {
 //		Required: state=[string] : current state of the requested resource
 //		Required: dest=[string] : destination state of the requested resource
 //		Required: owner=[string] : requester of the resource
+//		Optional: request_id=[string] : identifies retries of the same request
+//		Optional: priority=[int] : priority of the request, higher priorities are served first
 func handleAcquire(r *ranch.Ranch) http.HandlerFunc {
 	return func(res http.ResponseWriter, req *http.Request) {
 		logrus.WithField("handler", "handleStart").Infof("From %v", req.RemoteAddr)
@@ -148,9 +164,21 @@ func handleAcquire(r *ranch.Ranch) http.HandlerFunc {
 			return
 		}
 
-		logrus.Infof("Request for a %v %v from %v, dest %v", state, rtype, owner, dest)
+		requestID := req.URL.Query().Get("request_id")
+		priority := 0
+		if p := req.URL.Query().Get("priority"); p != "" {
+			var err error
+			if priority, err = strconv.Atoi(p); err != nil {
+				msg := fmt.Sprintf("Priority %q must be an integer.", p)
+				logrus.Warning(msg)
+				http.Error(res, msg, http.StatusBadRequest)
+				return
+			}
+		}
+
+		logrus.Infof("Request for a %v %v from %v, dest %v, priority %d", state, rtype, owner, dest, priority)
 
-		resource, err := r.Acquire(rtype, state, dest, owner)
+		resource, err := r.AcquireWithPriority(rtype, state, dest, owner, requestID, priority)
 
 		if err != nil {
 			logrus.WithError(err).Errorf("No available resource")
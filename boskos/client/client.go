@@ -33,6 +33,7 @@ import (
 
 	"sync"
 
+	"github.com/google/uuid"
 	"github.com/hashicorp/go-multierror"
 	"github.com/sirupsen/logrus"
 	"k8s.io/test-infra/boskos/common"
@@ -44,6 +45,9 @@ var (
 	ErrNotFound = errors.New("resources not found")
 	// ErrAlreadyInUse is returned by Acquire when resources are already being requested.
 	ErrAlreadyInUse = errors.New("resources already used by another user")
+	// ErrQuotaExceeded is returned by Acquire when the owner holds as many
+	// resources as its quota allows.
+	ErrQuotaExceeded = errors.New("resource quota of the owner exceeded")
 	// ErrContextRequired is returned by AcquireWait and AcquireByStateWait when
 	// they are invoked with a nil context.
 	ErrContextRequired = errors.New("context required")
@@ -55,6 +59,14 @@ type Client struct {
 	// boskos endpoint.
 	Dialer DialerWithRetry
 
+	// Priority is the priority of the acquisitions of the client. Requests
+	// waiting for resources are served in order of priority.
+	Priority int
+
+	// heartbeatInterval is how often resources acquired with
+	// AcquireWaitWithHeartbeat are updated
+	heartbeatInterval time.Duration
+
 	// http is the http.Client used to interact with the boskos REST API
 	http http.Client
 
@@ -72,9 +84,10 @@ type Client struct {
 func NewClient(owner string, url string) *Client {
 
 	client := &Client{
-		url:     url,
-		owner:   owner,
-		storage: storage.NewMemoryStorage(),
+		url:               url,
+		owner:             owner,
+		storage:           storage.NewMemoryStorage(),
+		heartbeatInterval: 5 * time.Minute,
 	}
 
 	// Configure the dialer to attempt three additional times to establish
@@ -110,7 +123,11 @@ func NewClient(owner string, url string) *Client {
 // Acquire asks boskos for a resource of certain type in certain state, and set the resource to dest state.
 // Returns the resource on success.
 func (c *Client) Acquire(rtype, state, dest string) (*common.Resource, error) {
-	r, err := c.acquire(rtype, state, dest)
+	return c.acquireWithID(rtype, state, dest, "")
+}
+
+func (c *Client) acquireWithID(rtype, state, dest, requestID string) (*common.Resource, error) {
+	r, err := c.acquire(rtype, state, dest, requestID)
 	if err != nil {
 		return nil, err
 	}
@@ -124,17 +141,19 @@ func (c *Client) Acquire(rtype, state, dest string) (*common.Resource, error) {
 }
 
 // AcquireWait blocks until Acquire returns the specified resource or the
-// provided context is cancelled or its deadline exceeded.
+// provided context is cancelled or its deadline exceeded. While it waits,
+// boskos keeps its place in the queue of requests for the resource type.
 func (c *Client) AcquireWait(ctx context.Context, rtype, state, dest string) (*common.Resource, error) {
 	if ctx == nil {
 		return nil, ErrContextRequired
 	}
+	requestID := uuid.New().String()
 	// Try to acquire the resource until available or the context is
 	// cancelled or its deadline exceeded.
 	for {
-		r, err := c.Acquire(rtype, state, dest)
+		r, err := c.acquireWithID(rtype, state, dest, requestID)
 		if err != nil {
-			if err == ErrAlreadyInUse || err == ErrNotFound {
+			if err == ErrAlreadyInUse || err == ErrNotFound || err == ErrQuotaExceeded {
 				select {
 				case <-ctx.Done():
 					return nil, err
@@ -148,6 +167,62 @@ func (c *Client) AcquireWait(ctx context.Context, rtype, state, dest string) (*c
 	}
 }
 
+// AcquireWaitWithHeartbeat waits up to timeout for a free resource of the
+// type and acquires it as busy. A zero timeout waits as long as the context
+// allows. The resource is kept from being reset with heartbeats until the
+// context is done, when it is released as dirty unless it was released
+// before, so callers do not need their own retry and heartbeat loops.
+func (c *Client) AcquireWaitWithHeartbeat(ctx context.Context, rtype string, timeout time.Duration) (*common.Resource, error) {
+	if ctx == nil {
+		return nil, ErrContextRequired
+	}
+	waitCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	r, err := c.AcquireWait(waitCtx, rtype, common.Free, common.Busy)
+	if err != nil {
+		return nil, err
+	}
+	go c.heartbeat(ctx, r.Name)
+	return r, nil
+}
+
+// heartbeat updates a busy resource until the context is done, and then
+// releases it as dirty. It stops once the resource is released.
+func (c *Client) heartbeat(ctx context.Context, name string) {
+	ticker := time.NewTicker(c.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if c.holds(name) {
+				if err := c.ReleaseOne(name, common.Dirty); err != nil {
+					logrus.WithError(err).Errorf("failed to release %s", name)
+				}
+			}
+			return
+		case <-ticker.C:
+			if !c.holds(name) {
+				return
+			}
+			if err := c.UpdateOne(name, common.Busy, nil); err != nil {
+				logrus.WithError(err).Warningf("failed to update %s", name)
+			}
+		}
+	}
+}
+
+// holds tells whether the client holds the resource
+func (c *Client) holds(name string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, err := c.storage.Get(name)
+	return err == nil
+}
+
 // AcquireByState asks boskos for a resources of certain type, and set the resource to dest state.
 // Returns a list of resources on success.
 func (c *Client) AcquireByState(state, dest string, names []string) ([]common.Resource, error) {
@@ -332,9 +407,9 @@ func (c *Client) updateLocalResource(i common.Item, state string, data *common.U
 	return c.storage.Update(res)
 }
 
-func (c *Client) acquire(rtype, state, dest string) (*common.Resource, error) {
-	resp, err := c.httpPost(fmt.Sprintf("%v/acquire?type=%v&state=%v&dest=%v&owner=%v",
-		c.url, rtype, state, dest, c.owner), "", nil)
+func (c *Client) acquire(rtype, state, dest, requestID string) (*common.Resource, error) {
+	resp, err := c.httpPost(fmt.Sprintf("%v/acquire?type=%v&state=%v&dest=%v&owner=%v&request_id=%v&priority=%d",
+		c.url, rtype, state, dest, c.owner, requestID, c.Priority), "", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -360,6 +435,8 @@ func (c *Client) acquire(rtype, state, dest string) (*common.Resource, error) {
 		return nil, ErrAlreadyInUse
 	case http.StatusNotFound:
 		return nil, ErrNotFound
+	case http.StatusTooManyRequests:
+		return nil, ErrQuotaExceeded
 	}
 	return nil, fmt.Errorf("status %s, status code %v", resp.Status, resp.StatusCode)
 }
@@ -17,11 +17,13 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -257,3 +259,71 @@ func TestMetric(t *testing.T) {
 		t.Errorf("wrong metric, got %v, want %v", metric, expectMetric)
 	}
 }
+
+func TestAcquireWaitWithHeartbeat(t *testing.T) {
+	var lock sync.Mutex
+	var updates int
+	released := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch r.URL.Path {
+		case "/acquire":
+			if r.URL.Query().Get("request_id") == "" {
+				t.Error("expected waiting acquisition to send a request ID")
+			}
+			fmt.Fprint(w, FakeRes)
+		case "/update":
+			updates++
+		case "/release":
+			released <- r.URL.Query().Get("dest")
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient("user", ts.URL)
+	c.heartbeatInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	res, err := c.AcquireWaitWithHeartbeat(ctx, "t", time.Minute)
+	if err != nil {
+		t.Fatalf("failed to acquire resource: %v", err)
+	}
+	if res.Name != "res" {
+		t.Errorf("got resource name %v, expect res", res.Name)
+	}
+	time.Sleep(100 * time.Millisecond)
+	lock.Lock()
+	if updates == 0 {
+		t.Error("expected heartbeats to update the resource")
+	}
+	lock.Unlock()
+
+	cancel()
+	select {
+	case dest := <-released:
+		if dest != common.Dirty {
+			t.Errorf("expected resource to be released as dirty, got %s", dest)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the resource to be released")
+	}
+	if c.HasResource() {
+		t.Error("expected client not to hold the resource after cancellation")
+	}
+}
+
+func TestAcquireWaitWithHeartbeatTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	c := NewClient("user", ts.URL)
+	start := time.Now()
+	if _, err := c.AcquireWaitWithHeartbeat(context.Background(), "t", 100*time.Millisecond); err != ErrNotFound {
+		t.Errorf("expected %v after the timeout, got %v", ErrNotFound, err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected to stop waiting after the timeout, waited %v", elapsed)
+	}
+}
@@ -38,6 +38,8 @@ const (
 	Cleaning = "cleaning"
 	// Leased state defines a resource being leased in order to make a new resource
 	Leased = "leased"
+	// ToBeDeleted state defines a dynamic resource being deleted
+	ToBeDeleted = "toBeDeleted"
 	// Other is used to agglomerate unspecified states for metrics reporting
 	Other = "other"
 )
@@ -76,9 +78,68 @@ type ResourceEntry struct {
 	Names []string `json:"names,flow"`
 }
 
+// LifecycleHook defines how boskos cleans up dirty resources of a type.
+// Exactly one of URL and Command must be set.
+type LifecycleHook struct {
+	// Type is the type of resources the hook cleans up
+	Type string `json:"type"`
+	// URL receives a POST with the resource as JSON body, a 2xx response
+	// means the resource is clean
+	URL string `json:"url,omitempty"`
+	// Command is run with the name of the resource as last argument,
+	// exiting with zero means the resource is clean
+	Command []string `json:"command,omitempty"`
+	// Timeout is the maximum duration of the hook, defaults to one hour
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// ProvisionerConfig defines how boskos creates and deletes dynamic
+// resources. Either URL or both CreateCommand and DeleteCommand must be set.
+type ProvisionerConfig struct {
+	// URL receives a POST to <url>/create?type=<type> answered with the
+	// created resource as JSON, and a POST to <url>/delete with the
+	// resource to delete as JSON body
+	URL string `json:"url,omitempty"`
+	// CreateCommand is run with the type as last argument and prints the
+	// name of the created resource
+	CreateCommand []string `json:"createCommand,omitempty"`
+	// DeleteCommand is run with the name of the resource as last argument
+	DeleteCommand []string `json:"deleteCommand,omitempty"`
+}
+
+// DynamicResourceEntry defines a type of resources boskos creates on demand
+// instead of managing a static list of them
+type DynamicResourceEntry struct {
+	Type string `json:"type"`
+	// MinCount resources are kept even when idle
+	MinCount int `json:"minCount,omitempty"`
+	// MaxCount is the maximum number of resources of the type
+	MaxCount int `json:"maxCount"`
+	// IdleTimeout is how long a resource stays free before it is deleted,
+	// defaults to one hour
+	IdleTimeout string            `json:"idleTimeout,omitempty"`
+	Provisioner ProvisionerConfig `json:"provisioner"`
+}
+
+// Quota limits the number of resources of a type that a group of owners
+// can hold at once
+type Quota struct {
+	Type string `json:"type"`
+	// Owners is a glob matching the owners sharing the quota, e.g.
+	// "pull-kubernetes-*"
+	Owners       string `json:"owners"`
+	MaxResources int    `json:"maxResources"`
+}
+
 // BoskosConfig defines config used by boskos server
 type BoskosConfig struct {
 	Resources []ResourceEntry `json:"resources,flow"`
+	// Hooks clean up resources when they are released dirty
+	Hooks []LifecycleHook `json:"hooks,omitempty"`
+	// DynamicResources are created when needed and deleted when idle
+	DynamicResources []DynamicResourceEntry `json:"dynamicResources,omitempty"`
+	// Quotas limit the resources owners can hold
+	Quotas []Quota `json:"quotas,omitempty"`
 }
 
 // Metric contains analytics about a specific resource type
@@ -20,6 +20,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"reflect"
 
 	"k8s.io/test-infra/boskos/common"
 
@@ -98,6 +99,8 @@ type Type struct {
 	Singular, Plural string
 	Object           Object
 	Collection       Collection
+	// PrinterColumns are shown by kubectl when listing objects of the type
+	PrinterColumns []apiextensionsv1beta1.CustomResourceColumnDefinition
 }
 
 // Object extends the runtime.Object interface. CRD are just a representation of the actual boskos object
@@ -170,12 +173,25 @@ func registerResource(config *rest.Config, t Type) error {
 				Kind:     t.Kind,
 				ListKind: t.ListKind,
 			},
+			AdditionalPrinterColumns: t.PrinterColumns,
 		},
 	}
-	if _, err := c.ApiextensionsV1beta1().CustomResourceDefinitions().Create(crd); err != nil && !apierrors.IsAlreadyExists(err) {
+	crds := c.ApiextensionsV1beta1().CustomResourceDefinitions()
+	_, err = crds.Create(crd)
+	if !apierrors.IsAlreadyExists(err) {
 		return err
 	}
-	return nil
+	// CRDs registered by earlier versions lack the printer columns
+	existing, err := crds.Get(crd.Name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(existing.Spec.AdditionalPrinterColumns, t.PrinterColumns) {
+		return nil
+	}
+	existing.Spec.AdditionalPrinterColumns = t.PrinterColumns
+	_, err = crds.Update(existing)
+	return err
 }
 
 // newDummyClient creates a in memory client representation for testing, such that we do not need to use a kubernetes API Server.
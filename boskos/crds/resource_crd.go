@@ -17,13 +17,30 @@ limitations under the License.
 package crds
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"k8s.io/test-infra/boskos/common"
 
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// TypeLabel is the label holding the type of a resource, if it is a valid label value
+	TypeLabel = group + "/type"
+	// StateLabel is the label holding the state of a resource, if it is a valid label value
+	StateLabel = group + "/state"
+
+	// ResourceReady is True when a resource is free to be acquired
+	ResourceReady ResourceConditionType = "Ready"
+	// ResourceLeased is True when a resource is owned by a client
+	ResourceLeased ResourceConditionType = "Leased"
 )
 
 var (
@@ -35,6 +52,12 @@ var (
 		Plural:     "resources",
 		Object:     &ResourceObject{},
 		Collection: &ResourceCollection{},
+		PrinterColumns: []apiextensionsv1beta1.CustomResourceColumnDefinition{
+			{Name: "Type", Type: "string", JSONPath: ".spec.type"},
+			{Name: "State", Type: "string", JSONPath: ".status.state"},
+			{Name: "Owner", Type: "string", JSONPath: ".status.owner"},
+			{Name: "Last-Update", Type: "date", JSONPath: ".status.lastUpdate"},
+		},
 	}
 )
 
@@ -65,10 +88,52 @@ type ResourceSpec struct {
 
 // ResourceStatus holds information that are likely to change
 type ResourceStatus struct {
-	State      string           `json:"state,omitempty"`
-	Owner      string           `json:"owner"`
-	LastUpdate time.Time        `json:"lastUpdate,omitempty"`
-	UserData   *common.UserData `json:"userData,omitempty"`
+	State      string              `json:"state,omitempty"`
+	Owner      string              `json:"owner"`
+	LastUpdate time.Time           `json:"lastUpdate,omitempty"`
+	UserData   *common.UserData    `json:"userData,omitempty"`
+	Conditions []ResourceCondition `json:"conditions,omitempty"`
+}
+
+// ResourceConditionType is the type of a condition of a resource
+type ResourceConditionType string
+
+// ResourceCondition describes an aspect of the state of a resource, so it can
+// be inspected with kubectl
+type ResourceCondition struct {
+	Type               ResourceConditionType  `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime v1.Time                `json:"lastTransitionTime,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+}
+
+// setCondition updates the condition of the given type, only moving its
+// transition time if the status changed
+func (in *ResourceStatus) setCondition(condition ResourceCondition, now time.Time) {
+	for i := range in.Conditions {
+		if in.Conditions[i].Type != condition.Type {
+			continue
+		}
+		condition.LastTransitionTime = in.Conditions[i].LastTransitionTime
+		if in.Conditions[i].Status != condition.Status {
+			condition.LastTransitionTime = v1.NewTime(now)
+		}
+		in.Conditions[i] = condition
+		return
+	}
+	condition.LastTransitionTime = v1.NewTime(now)
+	in.Conditions = append(in.Conditions, condition)
+}
+
+// GetCondition returns the condition of the given type, if the resource has one
+func (in *ResourceStatus) GetCondition(t ResourceConditionType) (ResourceCondition, bool) {
+	for _, condition := range in.Conditions {
+		if condition.Type == t {
+			return condition, true
+		}
+	}
+	return ResourceCondition{}, false
 }
 
 // GetName returns a unique identifier for a given resource
@@ -82,6 +147,10 @@ func (in *ResourceObject) deepCopyInto(out *ResourceObject) {
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
 	out.Status = in.Status
+	if in.Status.Conditions != nil {
+		out.Status.Conditions = make([]ResourceCondition, len(in.Status.Conditions))
+		copy(out.Status.Conditions, in.Status.Conditions)
+	}
 }
 
 func (in *ResourceObject) deepCopy() *ResourceObject {
@@ -124,6 +193,40 @@ func (in *ResourceObject) fromResource(r common.Resource) {
 	in.Status.State = r.State
 	in.Status.LastUpdate = r.LastUpdate
 	in.Status.UserData = r.UserData
+	in.setLabels()
+}
+
+// setLabels labels the resource with its type and state, so resources can be
+// selected with kubectl
+func (in *ResourceObject) setLabels() {
+	if in.Labels == nil {
+		in.Labels = map[string]string{}
+	}
+	for label, value := range map[string]string{TypeLabel: in.Spec.Type, StateLabel: in.Status.State} {
+		if len(validation.IsValidLabelValue(value)) == 0 {
+			in.Labels[label] = value
+		} else {
+			delete(in.Labels, label)
+		}
+	}
+}
+
+func (in *ResourceObject) setConditions(now time.Time) {
+	ready := ResourceCondition{Type: ResourceReady, Status: corev1.ConditionFalse, Reason: strings.Title(in.Status.State)}
+	if in.Status.State == common.Free {
+		ready.Status = corev1.ConditionTrue
+	} else {
+		ready.Message = fmt.Sprintf("resource is %s", in.Status.State)
+	}
+	in.Status.setCondition(ready, now)
+
+	leased := ResourceCondition{Type: ResourceLeased, Status: corev1.ConditionFalse, Reason: "Released"}
+	if in.Status.Owner != "" {
+		leased.Status = corev1.ConditionTrue
+		leased.Reason = "Acquired"
+		leased.Message = fmt.Sprintf("resource is owned by %s", in.Status.Owner)
+	}
+	in.Status.setCondition(leased, now)
 }
 
 // FromItem implements Object interface
@@ -131,6 +234,7 @@ func (in *ResourceObject) FromItem(i common.Item) {
 	r, err := common.ItemToResource(i)
 	if err == nil {
 		in.fromResource(r)
+		in.setConditions(time.Now())
 	}
 }
 
@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crds
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/test-infra/boskos/common"
+)
+
+func TestResourceConditions(t *testing.T) {
+	start := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name           string
+		resource       common.Resource
+		ready, leased  corev1.ConditionStatus
+		readyReason    string
+		readyChanged   bool
+		leasedChanged  bool
+		expectedLabels map[string]string
+	}{
+		{
+			name:           "free resource",
+			resource:       common.Resource{Name: "res", Type: "t", State: common.Free},
+			ready:          corev1.ConditionTrue,
+			leased:         corev1.ConditionFalse,
+			readyReason:    "Free",
+			expectedLabels: map[string]string{TypeLabel: "t", StateLabel: common.Free},
+		},
+		{
+			name:           "acquired resource",
+			resource:       common.Resource{Name: "res", Type: "t", State: common.Busy, Owner: "o"},
+			ready:          corev1.ConditionFalse,
+			leased:         corev1.ConditionTrue,
+			readyReason:    "Busy",
+			readyChanged:   true,
+			leasedChanged:  true,
+			expectedLabels: map[string]string{TypeLabel: "t", StateLabel: common.Busy},
+		},
+		{
+			name:           "released dirty resource",
+			resource:       common.Resource{Name: "res", Type: "t", State: common.Dirty},
+			ready:          corev1.ConditionFalse,
+			leased:         corev1.ConditionFalse,
+			readyReason:    "Dirty",
+			readyChanged:   true,
+			expectedLabels: map[string]string{TypeLabel: "t", StateLabel: common.Dirty},
+		},
+		{
+			name:           "type not usable as label",
+			resource:       common.Resource{Name: "res", Type: "not a label", State: common.Free},
+			ready:          corev1.ConditionTrue,
+			leased:         corev1.ConditionFalse,
+			readyReason:    "Free",
+			expectedLabels: map[string]string{StateLabel: common.Free},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &ResourceObject{}
+			obj.fromResource(common.Resource{Name: "res", Type: "t", State: common.Free})
+			obj.setConditions(start)
+			later := start.Add(time.Minute)
+			obj.fromResource(tc.resource)
+			obj.setConditions(later)
+
+			for _, expected := range []struct {
+				t       ResourceConditionType
+				status  corev1.ConditionStatus
+				changed bool
+			}{
+				{t: ResourceReady, status: tc.ready, changed: tc.readyChanged},
+				{t: ResourceLeased, status: tc.leased, changed: tc.leasedChanged},
+			} {
+				condition, ok := obj.Status.GetCondition(expected.t)
+				if !ok {
+					t.Fatalf("expected resource to have condition %s", expected.t)
+				}
+				if condition.Status != expected.status {
+					t.Errorf("expected condition %s to be %s, got %s", expected.t, expected.status, condition.Status)
+				}
+				transition := start
+				if expected.changed {
+					transition = later
+				}
+				if !condition.LastTransitionTime.Time.Equal(transition) {
+					t.Errorf("expected condition %s to transition at %v, got %v", expected.t, transition, condition.LastTransitionTime)
+				}
+			}
+			if ready, _ := obj.Status.GetCondition(ResourceReady); ready.Reason != tc.readyReason {
+				t.Errorf("expected ready reason %s, got %s", tc.readyReason, ready.Reason)
+			}
+			if len(obj.Status.Conditions) != 2 {
+				t.Errorf("expected two conditions, got %v", obj.Status.Conditions)
+			}
+			if len(obj.Labels) != len(tc.expectedLabels) {
+				t.Errorf("expected labels %v, got %v", tc.expectedLabels, obj.Labels)
+			}
+			for label, value := range tc.expectedLabels {
+				if obj.Labels[label] != value {
+					t.Errorf("expected label %s=%s, got %v", label, value, obj.Labels)
+				}
+			}
+		})
+	}
+}
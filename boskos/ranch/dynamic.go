@@ -0,0 +1,366 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ranch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/boskos/common"
+)
+
+const (
+	// defaultIdleTimeout is how long dynamic resources stay free before
+	// they are deleted if their type does not configure it
+	defaultIdleTimeout = time.Hour
+	// provisionTimeout is the maximum duration of creating or deleting a
+	// dynamic resource
+	provisionTimeout = time.Hour
+)
+
+// Provisioner creates and deletes the resources of a dynamic type, e.g.
+// GCP projects or AWS accounts from a factory.
+type Provisioner interface {
+	// Create creates a resource of the type and returns its name and
+	// user data
+	Create(ctx context.Context, rtype string) (common.Resource, error)
+	// Delete deletes the resource
+	Delete(ctx context.Context, res common.Resource) error
+}
+
+// dynamicType tracks a dynamic resource type and its pending creations
+type dynamicType struct {
+	entry       common.DynamicResourceEntry
+	idleTimeout time.Duration
+	provisioner Provisioner
+	creating    int
+}
+
+func validateDynamicResources(config *common.BoskosConfig) error {
+	static := map[string]bool{}
+	for _, entry := range config.Resources {
+		static[entry.Type] = true
+	}
+	types := map[string]bool{}
+	for _, entry := range config.DynamicResources {
+		if entry.Type == "" {
+			return fmt.Errorf("dynamic resource must specify its type")
+		}
+		if types[entry.Type] {
+			return fmt.Errorf("dynamic resource type %s is defined more than once", entry.Type)
+		}
+		types[entry.Type] = true
+		if static[entry.Type] {
+			return fmt.Errorf("dynamic resource type %s also has static resources", entry.Type)
+		}
+		if entry.MaxCount <= 0 || entry.MinCount < 0 || entry.MinCount > entry.MaxCount {
+			return fmt.Errorf("dynamic resource type %s must have 0 <= minCount <= maxCount and maxCount > 0", entry.Type)
+		}
+		if entry.IdleTimeout != "" {
+			if _, err := time.ParseDuration(entry.IdleTimeout); err != nil {
+				return fmt.Errorf("dynamic resource type %s has invalid idleTimeout: %v", entry.Type, err)
+			}
+		}
+		p := entry.Provisioner
+		hasCommand := len(p.CreateCommand) > 0 || len(p.DeleteCommand) > 0
+		hasCommands := len(p.CreateCommand) > 0 && len(p.DeleteCommand) > 0
+		if p.URL != "" && hasCommand || p.URL == "" && !hasCommands {
+			return fmt.Errorf("provisioner of dynamic resource type %s must specify either url or both createCommand and deleteCommand", entry.Type)
+		}
+	}
+	return nil
+}
+
+// SetDynamicResources replaces the dynamic resource types of the ranch
+func (r *Ranch) SetDynamicResources(entries []common.DynamicResourceEntry) {
+	r.dynamicLock.Lock()
+	defer r.dynamicLock.Unlock()
+	dynamic := map[string]*dynamicType{}
+	for _, entry := range entries {
+		// keep tracking the pending creations of known types
+		dt, ok := r.dynamic[entry.Type]
+		if !ok {
+			dt = &dynamicType{}
+		}
+		dt.entry = entry
+		dt.idleTimeout = defaultIdleTimeout
+		if entry.IdleTimeout != "" {
+			dt.idleTimeout, _ = time.ParseDuration(entry.IdleTimeout)
+		}
+		dt.provisioner = r.newProvisioner(entry.Provisioner)
+		dynamic[entry.Type] = dt
+	}
+	r.dynamic = dynamic
+}
+
+func (r *Ranch) isDynamic(rtype string) bool {
+	r.dynamicLock.Lock()
+	defer r.dynamicLock.Unlock()
+	_, ok := r.dynamic[rtype]
+	return ok
+}
+
+// provisionIfNeeded creates a resource of a dynamic type in the background
+// unless the type is at its maximum count. It must be called with the
+// resources lock held and all current resources.
+func (r *Ranch) provisionIfNeeded(rtype string, resources []common.Resource) {
+	count := 0
+	for _, res := range resources {
+		if res.Type == rtype {
+			count++
+		}
+	}
+	r.dynamicLock.Lock()
+	defer r.dynamicLock.Unlock()
+	dt, ok := r.dynamic[rtype]
+	if !ok || count+dt.creating >= dt.entry.MaxCount {
+		return
+	}
+	dt.creating++
+	go r.create(rtype, dt, dt.provisioner)
+}
+
+// create creates a resource of a dynamic type and adds it to the ranch. It
+// must be started with the dynamic lock held and a creation counted.
+func (r *Ranch) create(rtype string, dt *dynamicType, provisioner Provisioner) {
+	defer func() {
+		r.dynamicLock.Lock()
+		dt.creating--
+		r.dynamicLock.Unlock()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), provisionTimeout)
+	defer cancel()
+	created, err := provisioner.Create(ctx, rtype)
+	if err != nil {
+		logrus.WithError(err).WithField("type", rtype).Error("Failed to create dynamic resource.")
+		return
+	}
+
+	r.resourcesLock.Lock()
+	defer r.resourcesLock.Unlock()
+	res := common.NewResource(created.Name, rtype, common.Free, "", r.UpdateTime())
+	if created.UserData != nil {
+		res.UserData = created.UserData
+	}
+	if err := r.Storage.AddResource(res); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{"resource": res.Name, "type": rtype}).Error("Failed to add dynamic resource.")
+		return
+	}
+	logrus.WithFields(logrus.Fields{"resource": res.Name, "type": rtype}).Info("Created dynamic resource.")
+}
+
+// SyncDynamicResources creates resources of dynamic types with less than
+// their minimum count and deletes those that stayed free for longer than
+// the idle timeout of their type, keeping the minimum count.
+func (r *Ranch) SyncDynamicResources() {
+	r.dynamicLock.Lock()
+	types := map[string]*dynamicType{}
+	for rtype, dt := range r.dynamic {
+		types[rtype] = dt
+	}
+	r.dynamicLock.Unlock()
+
+	var wg sync.WaitGroup
+	for rtype, dt := range types {
+		toDelete, provisioner := r.markForDeletion(rtype, dt)
+		for _, res := range toDelete {
+			wg.Add(1)
+			go func(res common.Resource) {
+				defer wg.Done()
+				r.delete(provisioner, res)
+			}(res)
+		}
+	}
+	wg.Wait()
+}
+
+// markForDeletion moves idle resources of a dynamic type above its minimum
+// count to ToBeDeleted, owned by boskos, and returns all resources to
+// delete with the provisioner deleting them. It also starts creating
+// resources up to the minimum count.
+func (r *Ranch) markForDeletion(rtype string, dt *dynamicType) ([]common.Resource, Provisioner) {
+	r.resourcesLock.Lock()
+	defer r.resourcesLock.Unlock()
+	r.dynamicLock.Lock()
+	minCount, idleTimeout, provisioner := dt.entry.MinCount, dt.idleTimeout, dt.provisioner
+	r.dynamicLock.Unlock()
+	resources, err := r.Storage.GetResources()
+	if err != nil {
+		logrus.WithError(err).Error("cannot find resources")
+		return nil, provisioner
+	}
+
+	count := 0
+	var idle, toDelete []common.Resource
+	now := r.UpdateTime()
+	for _, res := range resources {
+		if res.Type != rtype {
+			continue
+		}
+		count++
+		switch {
+		case res.State == common.ToBeDeleted && res.Owner == "":
+			toDelete = append(toDelete, res)
+		case res.State == common.ToBeDeleted && res.Owner == HookOwner && now.Sub(res.LastUpdate) > provisionTimeout:
+			// boskos was restarted while deleting the resource
+			toDelete = append(toDelete, res)
+		case res.State == common.Free && res.Owner == "" && now.Sub(res.LastUpdate) > idleTimeout:
+			idle = append(idle, res)
+		}
+	}
+	for _, res := range idle {
+		if count-len(toDelete) <= minCount {
+			break
+		}
+		toDelete = append(toDelete, res)
+	}
+
+	var marked []common.Resource
+	for _, res := range toDelete {
+		res.State = common.ToBeDeleted
+		res.Owner = HookOwner
+		res.LastUpdate = now
+		if err := r.Storage.UpdateResource(res); err != nil {
+			logrus.WithError(err).Errorf("could not update resource %s", res.Name)
+			continue
+		}
+		marked = append(marked, res)
+	}
+
+	r.dynamicLock.Lock()
+	defer r.dynamicLock.Unlock()
+	for ; count+dt.creating < dt.entry.MinCount; dt.creating++ {
+		go r.create(rtype, dt, dt.provisioner)
+	}
+	return marked, provisioner
+}
+
+func (r *Ranch) delete(provisioner Provisioner, res common.Resource) {
+	log := logrus.WithFields(logrus.Fields{"resource": res.Name, "type": res.Type})
+	ctx, cancel := context.WithTimeout(context.Background(), provisionTimeout)
+	defer cancel()
+	if err := provisioner.Delete(ctx, res); err != nil {
+		log.WithError(err).Error("Failed to delete dynamic resource.")
+		// release the resource to retry deleting it later
+		if err := r.Release(res.Name, common.ToBeDeleted, HookOwner); err != nil {
+			log.WithError(err).Error("Failed to release resource to be deleted.")
+		}
+		return
+	}
+
+	r.resourcesLock.Lock()
+	defer r.resourcesLock.Unlock()
+	if err := r.Storage.DeleteResource(res.Name); err != nil {
+		log.WithError(err).Error("Failed to remove deleted dynamic resource.")
+		return
+	}
+	log.Info("Deleted dynamic resource.")
+}
+
+func newProvisioner(config common.ProvisionerConfig) Provisioner {
+	if config.URL != "" {
+		return &urlProvisioner{url: strings.TrimSuffix(config.URL, "/")}
+	}
+	return &commandProvisioner{create: config.CreateCommand, delete: config.DeleteCommand}
+}
+
+// urlProvisioner creates and deletes resources with a HTTP service
+type urlProvisioner struct {
+	url string
+}
+
+func (p *urlProvisioner) post(ctx context.Context, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, p.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(resp.Body); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s responded with status %d: %s", p.url+path, resp.StatusCode, out.String())
+	}
+	return out.Bytes(), nil
+}
+
+func (p *urlProvisioner) Create(ctx context.Context, rtype string) (common.Resource, error) {
+	var res common.Resource
+	body, err := p.post(ctx, "/create?type="+url.QueryEscape(rtype), nil)
+	if err != nil {
+		return res, err
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return res, fmt.Errorf("failed to parse created resource: %v", err)
+	}
+	if res.Name == "" {
+		return res, fmt.Errorf("created resource has no name")
+	}
+	return res, nil
+}
+
+func (p *urlProvisioner) Delete(ctx context.Context, res common.Resource) error {
+	body, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	_, err = p.post(ctx, "/delete", body)
+	return err
+}
+
+// commandProvisioner creates and deletes resources with commands
+type commandProvisioner struct {
+	create []string
+	delete []string
+}
+
+func (p *commandProvisioner) Create(ctx context.Context, rtype string) (common.Resource, error) {
+	cmd := exec.CommandContext(ctx, p.create[0], append(p.create[1:], rtype)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return common.Resource{}, fmt.Errorf("create command failed: %v", err)
+	}
+	name := strings.TrimSpace(string(out))
+	if name == "" || strings.ContainsAny(name, " \n") {
+		return common.Resource{}, fmt.Errorf("create command must print the name of the resource, printed %q", name)
+	}
+	return common.Resource{Name: name, Type: rtype}, nil
+}
+
+func (p *commandProvisioner) Delete(ctx context.Context, res common.Resource) error {
+	cmd := exec.CommandContext(ctx, p.delete[0], append(p.delete[1:], res.Name)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("delete command failed: %v, output: %s", err, string(out))
+	}
+	return nil
+}
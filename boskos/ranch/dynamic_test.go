@@ -0,0 +1,280 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ranch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/test-infra/boskos/common"
+)
+
+type fakeProvisioner struct {
+	lock       sync.Mutex
+	created    int
+	deleted    []string
+	failDelete map[string]bool
+}
+
+func (p *fakeProvisioner) Create(ctx context.Context, rtype string) (common.Resource, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.created++
+	return common.Resource{Name: fmt.Sprintf("%s-%d", rtype, p.created), UserData: common.UserDataFromMap(common.UserDataMap{"id": "fake"})}, nil
+}
+
+func (p *fakeProvisioner) Delete(ctx context.Context, res common.Resource) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.failDelete[res.Name] {
+		return errors.New("injected failure")
+	}
+	p.deleted = append(p.deleted, res.Name)
+	return nil
+}
+
+func makeDynamicRanch(resources []common.Resource, entries []common.DynamicResourceEntry) (*Ranch, *fakeProvisioner) {
+	p := &fakeProvisioner{failDelete: map[string]bool{}}
+	r := MakeTestRanch(resources)
+	r.newProvisioner = func(common.ProvisionerConfig) Provisioner { return p }
+	r.SetDynamicResources(entries)
+	return r, p
+}
+
+// waitForResources waits until the ranch has the given number of resources
+func waitForResources(t *testing.T, r *Ranch, count int) []common.Resource {
+	for i := 0; i < 100; i++ {
+		r.resourcesLock.RLock()
+		resources, err := r.Storage.GetResources()
+		r.resourcesLock.RUnlock()
+		if err != nil {
+			t.Fatalf("failed to get resources: %v", err)
+		}
+		if len(resources) == count {
+			// wait for the creations to be accounted for
+			time.Sleep(10 * time.Millisecond)
+			return resources
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d resources", count)
+	return nil
+}
+
+func TestValidateDynamicResources(t *testing.T) {
+	provisioner := common.ProvisionerConfig{URL: "http://factory"}
+	testCases := []struct {
+		name    string
+		config  common.BoskosConfig
+		isValid bool
+	}{
+		{
+			name: "url and command provisioners",
+			config: common.BoskosConfig{DynamicResources: []common.DynamicResourceEntry{
+				{Type: "gce-project", MinCount: 1, MaxCount: 10, IdleTimeout: "30m", Provisioner: provisioner},
+				{Type: "aws-account", MaxCount: 5, Provisioner: common.ProvisionerConfig{CreateCommand: []string{"create"}, DeleteCommand: []string{"delete"}}},
+			}},
+			isValid: true,
+		},
+		{
+			name:   "missing type",
+			config: common.BoskosConfig{DynamicResources: []common.DynamicResourceEntry{{MaxCount: 1, Provisioner: provisioner}}},
+		},
+		{
+			name: "duplicate type",
+			config: common.BoskosConfig{DynamicResources: []common.DynamicResourceEntry{
+				{Type: "gce-project", MaxCount: 1, Provisioner: provisioner},
+				{Type: "gce-project", MaxCount: 1, Provisioner: provisioner},
+			}},
+		},
+		{
+			name: "type with static resources",
+			config: common.BoskosConfig{
+				Resources:        []common.ResourceEntry{{Type: "gce-project", Names: []string{"project"}}},
+				DynamicResources: []common.DynamicResourceEntry{{Type: "gce-project", MaxCount: 1, Provisioner: provisioner}},
+			},
+		},
+		{
+			name:   "no max count",
+			config: common.BoskosConfig{DynamicResources: []common.DynamicResourceEntry{{Type: "gce-project", Provisioner: provisioner}}},
+		},
+		{
+			name:   "min count above max count",
+			config: common.BoskosConfig{DynamicResources: []common.DynamicResourceEntry{{Type: "gce-project", MinCount: 2, MaxCount: 1, Provisioner: provisioner}}},
+		},
+		{
+			name:   "invalid idle timeout",
+			config: common.BoskosConfig{DynamicResources: []common.DynamicResourceEntry{{Type: "gce-project", MaxCount: 1, IdleTimeout: "soon", Provisioner: provisioner}}},
+		},
+		{
+			name: "only a create command",
+			config: common.BoskosConfig{DynamicResources: []common.DynamicResourceEntry{
+				{Type: "gce-project", MaxCount: 1, Provisioner: common.ProvisionerConfig{CreateCommand: []string{"create"}}},
+			}},
+		},
+		{
+			name: "url and commands",
+			config: common.BoskosConfig{DynamicResources: []common.DynamicResourceEntry{
+				{Type: "gce-project", MaxCount: 1, Provisioner: common.ProvisionerConfig{URL: "http://factory", CreateCommand: []string{"create"}, DeleteCommand: []string{"delete"}}},
+			}},
+		},
+	}
+	for _, tc := range testCases {
+		if err := validateDynamicResources(&tc.config); (err == nil) != tc.isValid {
+			t.Errorf("%s: expected valid %t, got error %v", tc.name, tc.isValid, err)
+		}
+	}
+}
+
+func TestAcquireProvisions(t *testing.T) {
+	r, _ := makeDynamicRanch(nil, []common.DynamicResourceEntry{{Type: "project", MaxCount: 1}})
+
+	if _, err := r.Acquire("project", common.Free, common.Busy, "user"); !AreErrorsEqual(err, &ResourceNotFound{"project"}) {
+		t.Fatalf("expected no resource before one is created, got %v", err)
+	}
+	resources := waitForResources(t, r, 1)
+	if res := resources[0]; res.Name != "project-1" || res.State != common.Free || res.Owner != "" {
+		t.Errorf("expected free project-1 to be created, got %v", res)
+	}
+
+	res, err := r.Acquire("project", common.Free, common.Busy, "user")
+	if err != nil {
+		t.Fatalf("expected created resource to be acquired, got %v", err)
+	}
+	var id string
+	if err := res.UserData.Extract("id", &id); err != nil || id != "fake" {
+		t.Errorf("expected user data of the provisioner to be kept, got %v", res.UserData.ToMap())
+	}
+
+	// the type is at its maximum count
+	if _, err := r.Acquire("project", common.Free, common.Busy, "user"); err == nil {
+		t.Error("expected no resource to be acquired above the maximum count")
+	}
+	time.Sleep(50 * time.Millisecond)
+	waitForResources(t, r, 1)
+}
+
+func TestSyncDynamicResources(t *testing.T) {
+	now := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	resources := []common.Resource{
+		common.NewResource("idle-1", "project", common.Free, "", now.Add(-2*time.Hour)),
+		common.NewResource("idle-2", "project", common.Free, "", now.Add(-3*time.Hour)),
+		common.NewResource("idle-fails", "project", common.Free, "", now.Add(-4*time.Hour)),
+		common.NewResource("recent", "project", common.Free, "", now.Add(-time.Minute)),
+		common.NewResource("busy", "project", common.Busy, "user", now.Add(-5*time.Hour)),
+		common.NewResource("static", "other", common.Free, "", now.Add(-5*time.Hour)),
+	}
+	r, p := makeDynamicRanch(resources, []common.DynamicResourceEntry{
+		{Type: "project", MinCount: 3, MaxCount: 10},
+		{Type: "cluster", MinCount: 2, MaxCount: 10},
+	})
+	r.UpdateTime = func() time.Time { return now }
+	p.failDelete["idle-fails"] = true
+
+	r.SyncDynamicResources()
+
+	// two idle projects are deleted to keep three, and two clusters are
+	// created to reach the minimum count
+	sort.Strings(p.deleted)
+	if expected := []string{"idle-2"}; len(p.deleted) != 1 || !reflect.DeepEqual(p.deleted, expected) {
+		t.Errorf("expected %v to be deleted, deleted %v", expected, p.deleted)
+	}
+	states := map[string]string{}
+	for _, res := range waitForResources(t, r, 7) {
+		states[res.Name] = res.State + "/" + res.Owner
+	}
+	expected := map[string]string{
+		"idle-1":     common.Free + "/",
+		"idle-fails": common.ToBeDeleted + "/",
+		"recent":     common.Free + "/",
+		"busy":       common.Busy + "/user",
+		"static":     common.Free + "/",
+		"cluster-1":  common.Free + "/",
+		"cluster-2":  common.Free + "/",
+	}
+	if !reflect.DeepEqual(states, expected) {
+		t.Errorf("expected resources %v, got %v", expected, states)
+	}
+
+	// resources that failed to be deleted are retried
+	p.failDelete = map[string]bool{}
+	r.SyncDynamicResources()
+	if len(p.deleted) != 2 || p.deleted[1] != "idle-fails" {
+		t.Errorf("expected deleting idle-fails to be retried, deleted %v", p.deleted)
+	}
+}
+
+func TestURLProvisioner(t *testing.T) {
+	var deleted common.Resource
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/create":
+			fmt.Fprintf(w, `{"name": "%s-created", "userdata": {"account": "123"}}`, req.URL.Query().Get("type"))
+		case "/delete":
+			if err := json.NewDecoder(req.Body).Decode(&deleted); err != nil {
+				t.Errorf("failed to decode resource: %v", err)
+			}
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+	p := newProvisioner(common.ProvisionerConfig{URL: server.URL + "/"})
+
+	res, err := p.Create(context.Background(), "aws-account")
+	if err != nil {
+		t.Fatalf("failed to create resource: %v", err)
+	}
+	if res.Name != "aws-account-created" || res.UserData.ToMap()["account"] != "123" {
+		t.Errorf("expected created resource with user data, got %v", res)
+	}
+	if err := p.Delete(context.Background(), common.Resource{Name: "aws-account-created", Type: "aws-account"}); err != nil {
+		t.Fatalf("failed to delete resource: %v", err)
+	}
+	if deleted.Name != "aws-account-created" {
+		t.Errorf("expected the resource to be sent for deletion, got %v", deleted)
+	}
+}
+
+func TestCommandProvisioner(t *testing.T) {
+	p := newProvisioner(common.ProvisionerConfig{
+		CreateCommand: []string{"sh", "-c", `echo "$0-created"`},
+		DeleteCommand: []string{"sh", "-c", `test "$0" = gce-project-created`},
+	})
+	res, err := p.Create(context.Background(), "gce-project")
+	if err != nil {
+		t.Fatalf("failed to create resource: %v", err)
+	}
+	if res.Name != "gce-project-created" {
+		t.Errorf("expected the printed name to be used, got %q", res.Name)
+	}
+	if err := p.Delete(context.Background(), res); err != nil {
+		t.Errorf("failed to delete resource: %v", err)
+	}
+	if err := p.Delete(context.Background(), common.Resource{Name: "other"}); err == nil {
+		t.Error("expected failing delete command to fail")
+	}
+}
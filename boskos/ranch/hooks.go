@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ranch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/boskos/common"
+)
+
+const (
+	// HookOwner owns resources while boskos runs their lifecycle hook or
+	// deletes them
+	HookOwner = "boskos"
+	// defaultHookTimeout is the timeout of hooks not configuring one
+	defaultHookTimeout = time.Hour
+	// hookConcurrency is the maximum number of hooks running at once
+	hookConcurrency = 20
+	// hookHeartbeat is how often resources are updated while their hook
+	// runs, so the reaper does not consider them stale
+	hookHeartbeat = time.Minute
+)
+
+func validateHooks(hooks []common.LifecycleHook) error {
+	types := map[string]bool{}
+	for _, hook := range hooks {
+		if hook.Type == "" {
+			return fmt.Errorf("hook must specify the type of resources it cleans up")
+		}
+		if types[hook.Type] {
+			return fmt.Errorf("more than one hook for resources of type %s", hook.Type)
+		}
+		types[hook.Type] = true
+		if (hook.URL == "") == (len(hook.Command) == 0) {
+			return fmt.Errorf("hook for resources of type %s must specify exactly one of url and command", hook.Type)
+		}
+		if hook.Timeout != "" {
+			if _, err := time.ParseDuration(hook.Timeout); err != nil {
+				return fmt.Errorf("hook for resources of type %s has invalid timeout: %v", hook.Type, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SetHooks replaces the lifecycle hooks of the ranch
+func (r *Ranch) SetHooks(hooks []common.LifecycleHook) {
+	r.hooksLock.Lock()
+	defer r.hooksLock.Unlock()
+	r.hooks = hooks
+}
+
+func (r *Ranch) getHooks() []common.LifecycleHook {
+	r.hooksLock.RLock()
+	defer r.hooksLock.RUnlock()
+	return r.hooks
+}
+
+// RunHooks cleans up all dirty resources of types with a lifecycle hook.
+// Resources are moved to cleaning while their hook runs, and then to free
+// if the hook succeeded or back to dirty to be retried if it failed.
+func (r *Ranch) RunHooks() {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, hookConcurrency)
+	for _, hook := range r.getHooks() {
+		for {
+			res, err := r.Acquire(hook.Type, common.Dirty, common.Cleaning, HookOwner)
+			if err != nil {
+				if _, ok := err.(*ResourceNotFound); !ok {
+					logrus.WithError(err).Errorf("failed to acquire dirty resource of type %s", hook.Type)
+				}
+				break
+			}
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(hook common.LifecycleHook, res common.Resource) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				r.cleanUp(hook, res)
+			}(hook, *res)
+		}
+	}
+	wg.Wait()
+}
+
+func (r *Ranch) cleanUp(hook common.LifecycleHook, res common.Resource) {
+	log := logrus.WithFields(logrus.Fields{"resource": res.Name, "type": res.Type})
+	timeout := defaultHookTimeout
+	if hook.Timeout != "" {
+		timeout, _ = time.ParseDuration(hook.Timeout)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.runHook(ctx, hook, res)
+	}()
+	heartbeat := time.NewTicker(hookHeartbeat)
+	defer heartbeat.Stop()
+	var err error
+	for running := true; running; {
+		select {
+		case <-heartbeat.C:
+			if err := r.Update(res.Name, HookOwner, common.Cleaning, nil); err != nil {
+				log.WithError(err).Warn("Failed to update resource while its hook runs.")
+			}
+		case err = <-done:
+			running = false
+		}
+	}
+
+	dest := common.Free
+	if err != nil {
+		log.WithError(err).Error("Lifecycle hook failed.")
+		dest = common.Dirty
+	} else {
+		log.Info("Lifecycle hook cleaned up resource.")
+	}
+	if err := r.Release(res.Name, dest, HookOwner); err != nil {
+		log.WithError(err).Errorf("Failed to release resource as %s.", dest)
+	}
+}
+
+// runHook calls the URL or runs the command of the hook for the resource
+func runHook(ctx context.Context, hook common.LifecycleHook, res common.Resource) error {
+	if len(hook.Command) > 0 {
+		cmd := exec.CommandContext(ctx, hook.Command[0], append(hook.Command[1:], res.Name)...)
+		cmd.Env = append(os.Environ(), "BOSKOS_RESOURCE_NAME="+res.Name, "BOSKOS_RESOURCE_TYPE="+res.Type)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("command failed: %v, output: %s", err, string(out))
+		}
+		return nil
+	}
+
+	body, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", hook.URL, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ranch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/test-infra/boskos/common"
+)
+
+func TestValidateHooks(t *testing.T) {
+	testCases := []struct {
+		name    string
+		hooks   []common.LifecycleHook
+		isValid bool
+	}{
+		{
+			name: "url and command hooks",
+			hooks: []common.LifecycleHook{
+				{Type: "gce-project", Command: []string{"/bin/janitor.py", "--hour=0"}, Timeout: "2h"},
+				{Type: "aws-account", URL: "http://aws-janitor/clean"},
+			},
+			isValid: true,
+		},
+		{
+			name:  "missing type",
+			hooks: []common.LifecycleHook{{URL: "http://janitor"}},
+		},
+		{
+			name: "duplicate type",
+			hooks: []common.LifecycleHook{
+				{Type: "gce-project", URL: "http://janitor"},
+				{Type: "gce-project", URL: "http://other-janitor"},
+			},
+		},
+		{
+			name:  "both url and command",
+			hooks: []common.LifecycleHook{{Type: "gce-project", URL: "http://janitor", Command: []string{"janitor"}}},
+		},
+		{
+			name:  "neither url nor command",
+			hooks: []common.LifecycleHook{{Type: "gce-project"}},
+		},
+		{
+			name:  "invalid timeout",
+			hooks: []common.LifecycleHook{{Type: "gce-project", URL: "http://janitor", Timeout: "forever"}},
+		},
+	}
+	for _, tc := range testCases {
+		if err := validateHooks(tc.hooks); (err == nil) != tc.isValid {
+			t.Errorf("%s: expected valid %t, got error %v", tc.name, tc.isValid, err)
+		}
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	resources := []common.Resource{
+		common.NewResource("dirty-ok", "project", common.Dirty, "", time.Time{}),
+		common.NewResource("dirty-fails", "project", common.Dirty, "", time.Time{}),
+		common.NewResource("busy", "project", common.Busy, "user", time.Time{}),
+		common.NewResource("dirty-without-hook", "cluster", common.Dirty, "", time.Time{}),
+	}
+	r := MakeTestRanch(resources)
+	r.SetHooks([]common.LifecycleHook{{Type: "project", URL: "http://janitor"}})
+	var lock sync.Mutex
+	var cleaned []string
+	r.runHook = func(ctx context.Context, hook common.LifecycleHook, res common.Resource) error {
+		r.resourcesLock.RLock()
+		current, err := r.Storage.GetResource(res.Name)
+		r.resourcesLock.RUnlock()
+		if err != nil || current.State != common.Cleaning || current.Owner != HookOwner {
+			t.Errorf("expected %s to be cleaning while its hook runs, got %v", res.Name, current)
+		}
+		lock.Lock()
+		cleaned = append(cleaned, res.Name)
+		lock.Unlock()
+		if res.Name == "dirty-fails" {
+			return errors.New("injected failure")
+		}
+		return nil
+	}
+
+	r.RunHooks()
+
+	sort.Strings(cleaned)
+	if expected := []string{"dirty-fails", "dirty-ok"}; !reflect.DeepEqual(cleaned, expected) {
+		t.Errorf("expected hooks to run for %v, ran for %v", expected, cleaned)
+	}
+	for name, expected := range map[string]string{
+		"dirty-ok":           common.Free,
+		"dirty-fails":        common.Dirty,
+		"busy":               common.Busy,
+		"dirty-without-hook": common.Dirty,
+	} {
+		res, err := r.Storage.GetResource(name)
+		if err != nil {
+			t.Fatalf("failed to get %s: %v", name, err)
+		}
+		if res.State != expected {
+			t.Errorf("expected %s to be %s, got %s", name, expected, res.State)
+		}
+		if name != "busy" && res.Owner != "" {
+			t.Errorf("expected %s to be released, owned by %s", name, res.Owner)
+		}
+	}
+}
+
+func TestRunHookURL(t *testing.T) {
+	var received common.Resource
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := json.NewDecoder(req.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode resource: %v", err)
+		}
+		if received.Name == "unclean" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	hook := common.LifecycleHook{Type: "project", URL: server.URL}
+
+	if err := runHook(context.Background(), hook, common.NewResource("project-1", "project", common.Cleaning, HookOwner, time.Time{})); err != nil {
+		t.Errorf("expected hook to succeed, got %v", err)
+	}
+	if received.Name != "project-1" || received.Type != "project" {
+		t.Errorf("expected the resource to be sent, got %v", received)
+	}
+	if err := runHook(context.Background(), hook, common.NewResource("unclean", "project", common.Cleaning, HookOwner, time.Time{})); err == nil {
+		t.Error("expected hook responding with an error status to fail")
+	}
+}
+
+func TestRunHookCommand(t *testing.T) {
+	res := common.NewResource("project-1", "project", common.Cleaning, HookOwner, time.Time{})
+	testCases := []struct {
+		name    string
+		command []string
+		fails   bool
+	}{
+		{
+			name:    "resource name is passed as argument",
+			command: []string{"sh", "-c", `test "$0" = project-1`},
+		},
+		{
+			name:    "resource is passed in environment",
+			command: []string{"sh", "-c", `test "$BOSKOS_RESOURCE_NAME/$BOSKOS_RESOURCE_TYPE" = project-1/project`},
+		},
+		{
+			name:    "failing command",
+			command: []string{"false"},
+			fails:   true,
+		},
+	}
+	for _, tc := range testCases {
+		err := runHook(context.Background(), common.LifecycleHook{Type: "project", Command: tc.command}, res)
+		if (err != nil) != tc.fails {
+			t.Errorf("%s: expected failure %t, got %v", tc.name, tc.fails, err)
+		}
+	}
+}
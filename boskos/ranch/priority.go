@@ -0,0 +1,188 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ranch
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/test-infra/boskos/common"
+)
+
+// requestTTL is how long a request waits in the queue without being
+// retried before it is forgotten
+const requestTTL = 30 * time.Second
+
+var acquireWait = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "boskos_acquire_wait_seconds",
+	Help:    "Time from the first attempt of a request to acquire a resource until it succeeded.",
+	Buckets: []float64{1, 10, 30, 60, 300, 600, 1800, 3600, 7200},
+}, []string{"type", "priority"})
+
+func init() {
+	prometheus.MustRegister(acquireWait)
+}
+
+// QuotaExceeded will be returned if acquiring a resource would exceed a quota of the owner.
+type QuotaExceeded struct {
+	quota common.Quota
+}
+
+func (q QuotaExceeded) Error() string {
+	return fmt.Sprintf("QuotaExceeded - owners %s already hold %d resources of type %s", q.quota.Owners, q.quota.MaxResources, q.quota.Type)
+}
+
+// request is an acquisition retried until it succeeds
+type request struct {
+	id        string
+	priority  int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// outranks tells whether the request is served before the other one
+func (r *request) outranks(other *request) bool {
+	if r.priority != other.priority {
+		return r.priority > other.priority
+	}
+	if !r.firstSeen.Equal(other.firstSeen) {
+		return r.firstSeen.Before(other.firstSeen)
+	}
+	return r.id < other.id
+}
+
+// requestQueue tracks the requests waiting for resources of a type in a state
+type requestQueue struct {
+	lock   sync.Mutex
+	queues map[string]map[string]*request
+}
+
+func queueKey(rtype, state string) string {
+	return rtype + "/" + state
+}
+
+// update records an attempt of the request and returns it with the number
+// of waiting requests it must give way to. Requests without an ID are not
+// recorded and give way to all waiting requests of equal priority.
+func (q *requestQueue) update(rtype, state, id string, priority int, now time.Time) (*request, int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.queues == nil {
+		q.queues = map[string]map[string]*request{}
+	}
+	queue, ok := q.queues[queueKey(rtype, state)]
+	if !ok {
+		queue = map[string]*request{}
+		q.queues[queueKey(rtype, state)] = queue
+	}
+	for key, waiting := range queue {
+		if now.Sub(waiting.lastSeen) > requestTTL {
+			delete(queue, key)
+		}
+	}
+
+	req := &request{id: id, priority: priority, firstSeen: now}
+	if id != "" {
+		if existing, ok := queue[id]; ok {
+			req = existing
+			req.priority = priority
+		} else {
+			queue[id] = req
+		}
+		req.lastSeen = now
+	}
+
+	ahead := 0
+	for _, waiting := range queue {
+		if waiting != req && waiting.outranks(req) {
+			ahead++
+		}
+	}
+	return req, ahead
+}
+
+// remove forgets a request once it acquired a resource
+func (q *requestQueue) remove(rtype, state, id string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if queue, ok := q.queues[queueKey(rtype, state)]; ok {
+		delete(queue, id)
+	}
+}
+
+// SetQuotas replaces the quotas of the ranch
+func (r *Ranch) SetQuotas(quotas []common.Quota) {
+	r.quotasLock.Lock()
+	defer r.quotasLock.Unlock()
+	r.quotas = quotas
+}
+
+// checkQuotas returns a QuotaExceeded error if the owner cannot acquire
+// another resource of the type. Resources held by boskos itself are not
+// subject to quotas.
+func (r *Ranch) checkQuotas(rtype, owner string, resources []common.Resource) error {
+	if owner == HookOwner {
+		return nil
+	}
+	r.quotasLock.RLock()
+	defer r.quotasLock.RUnlock()
+	for _, quota := range r.quotas {
+		if quota.Type != rtype {
+			continue
+		}
+		if matched, _ := path.Match(quota.Owners, owner); !matched {
+			continue
+		}
+		held := 0
+		for _, res := range resources {
+			if res.Type != rtype || res.Owner == "" || res.Owner == HookOwner {
+				continue
+			}
+			if matched, _ := path.Match(quota.Owners, res.Owner); matched {
+				held++
+			}
+		}
+		if held >= quota.MaxResources {
+			return &QuotaExceeded{quota: quota}
+		}
+	}
+	return nil
+}
+
+func validateQuotas(quotas []common.Quota) error {
+	for _, quota := range quotas {
+		if quota.Type == "" || quota.Owners == "" {
+			return fmt.Errorf("quota must specify the type of resources and the owners it applies to")
+		}
+		if _, err := path.Match(quota.Owners, ""); err != nil {
+			return fmt.Errorf("quota for resources of type %s has invalid owners pattern %q: %v", quota.Type, quota.Owners, err)
+		}
+		if quota.MaxResources <= 0 {
+			return fmt.Errorf("quota for resources of type %s and owners %s must allow at least one resource", quota.Type, quota.Owners)
+		}
+	}
+	return nil
+}
+
+func observeAcquired(req *request, rtype string, now time.Time) {
+	acquireWait.WithLabelValues(rtype, strconv.Itoa(req.priority)).Observe(now.Sub(req.firstSeen).Seconds())
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ranch
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/test-infra/boskos/common"
+)
+
+func TestAcquireWithPriority(t *testing.T) {
+	now := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := MakeTestRanch(nil)
+	r.UpdateTime = func() time.Time { return now }
+	added := 0
+	addFree := func() {
+		added++
+		r.Storage.AddResource(common.NewResource(string(rune('a'+added)), "project", common.Free, "", time.Time{}))
+	}
+
+	type attempt struct {
+		name      string
+		owner     string
+		requestID string
+		priority  int
+		acquires  bool
+	}
+	steps := []struct {
+		advance  time.Duration
+		addFree  int
+		attempts []attempt
+	}{
+		{
+			attempts: []attempt{
+				{name: "low priority request waits", owner: "low", requestID: "low"},
+				{name: "high priority request waits", owner: "high", requestID: "high", priority: 10},
+				{name: "request without ID waits", owner: "anonymous"},
+			},
+		},
+		{
+			advance: time.Second,
+			addFree: 1,
+			attempts: []attempt{
+				{name: "low priority request yields to high priority", owner: "low", requestID: "low"},
+				{name: "request without ID yields to waiting requests", owner: "anonymous"},
+				{name: "high priority request is served first", owner: "high", requestID: "high", priority: 10, acquires: true},
+			},
+		},
+		{
+			advance: time.Second,
+			addFree: 1,
+			attempts: []attempt{
+				{name: "later request of equal priority waits", owner: "later", requestID: "later"},
+				{name: "earliest request of equal priority is served", owner: "low", requestID: "low", acquires: true},
+			},
+		},
+		{
+			advance: requestTTL + time.Second,
+			addFree: 1,
+			attempts: []attempt{
+				{name: "requests that are not retried are forgotten", owner: "anonymous", acquires: true},
+			},
+		},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		for i := 0; i < step.addFree; i++ {
+			addFree()
+		}
+		for _, a := range step.attempts {
+			res, err := r.AcquireWithPriority("project", common.Free, common.Busy, a.owner, a.requestID, a.priority)
+			if a.acquires && err != nil {
+				t.Errorf("%s: expected to acquire a resource, got %v", a.name, err)
+			}
+			if !a.acquires && err == nil {
+				t.Errorf("%s: expected not to acquire a resource, got %s", a.name, res.Name)
+			}
+		}
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	resources := []common.Resource{
+		common.NewResource("held", "project", common.Busy, "pull-held", time.Time{}),
+		common.NewResource("free-1", "project", common.Free, "", time.Time{}),
+		common.NewResource("free-2", "project", common.Free, "", time.Time{}),
+		common.NewResource("cluster", "cluster", common.Free, "", time.Time{}),
+		common.NewResource("dirty", "project", common.Dirty, "", time.Time{}),
+	}
+	r := MakeTestRanch(resources)
+	r.SetQuotas([]common.Quota{{Type: "project", Owners: "pull-*", MaxResources: 1}})
+
+	if _, err := r.Acquire("project", common.Free, common.Busy, "pull-other"); err == nil {
+		t.Error("expected owner sharing an exhausted quota not to acquire a resource")
+	} else if _, ok := err.(*QuotaExceeded); !ok {
+		t.Errorf("expected owner sharing an exhausted quota not to acquire a resource, got %v", err)
+	}
+	if _, err := r.Acquire("cluster", common.Free, common.Busy, "pull-other"); err != nil {
+		t.Errorf("expected quota not to apply to other types, got %v", err)
+	}
+	if _, err := r.Acquire("project", common.Free, common.Busy, "ci-job"); err != nil {
+		t.Errorf("expected quota not to apply to other owners, got %v", err)
+	}
+	if _, err := r.Acquire("project", common.Dirty, common.Cleaning, HookOwner); err != nil {
+		t.Errorf("expected boskos not to be subject to quotas, got %v", err)
+	}
+	if err := r.Release("held", common.Dirty, "pull-held"); err != nil {
+		t.Fatalf("failed to release resource: %v", err)
+	}
+	if _, err := r.Acquire("project", common.Free, common.Busy, "pull-other"); err != nil {
+		t.Errorf("expected resource to be acquired once the quota allows, got %v", err)
+	}
+}
+
+func TestValidateQuotas(t *testing.T) {
+	testCases := []struct {
+		name    string
+		quota   common.Quota
+		isValid bool
+	}{
+		{
+			name:    "valid quota",
+			quota:   common.Quota{Type: "project", Owners: "pull-*", MaxResources: 5},
+			isValid: true,
+		},
+		{
+			name:  "missing owners",
+			quota: common.Quota{Type: "project", MaxResources: 5},
+		},
+		{
+			name:  "invalid owners pattern",
+			quota: common.Quota{Type: "project", Owners: "pull-[", MaxResources: 5},
+		},
+		{
+			name:  "no resources allowed",
+			quota: common.Quota{Type: "project", Owners: "*"},
+		},
+	}
+	for _, tc := range testCases {
+		if err := validateQuotas([]common.Quota{tc.quota}); (err == nil) != tc.isValid {
+			t.Errorf("%s: expected valid %t, got error %v", tc.name, tc.isValid, err)
+		}
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package ranch
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -32,8 +33,17 @@ import (
 type Ranch struct {
 	Storage       *Storage
 	resourcesLock sync.RWMutex
+	hooksLock     sync.RWMutex
+	hooks         []common.LifecycleHook
+	dynamicLock   sync.Mutex
+	dynamic       map[string]*dynamicType
+	quotasLock    sync.RWMutex
+	quotas        []common.Quota
+	requests      requestQueue
 	// For testing
-	UpdateTime func() time.Time
+	UpdateTime     func() time.Time
+	runHook        func(ctx context.Context, hook common.LifecycleHook, res common.Resource) error
+	newProvisioner func(config common.ProvisionerConfig) Provisioner
 }
 
 func updateTime() time.Time {
@@ -78,7 +88,9 @@ func (s StateNotMatch) Error() string {
 func NewRanch(config string, s *Storage) (*Ranch, error) {
 	newRanch := &Ranch{
 		Storage:    s,
-		UpdateTime: updateTime,
+		UpdateTime:     updateTime,
+		runHook:        runHook,
+		newProvisioner: newProvisioner,
 	}
 	if config != "" {
 		if err := newRanch.SyncConfig(config); err != nil {
@@ -91,13 +103,26 @@ func NewRanch(config string, s *Storage) (*Ranch, error) {
 
 // Acquire checks out a type of resource in certain state without an owner,
 // and move the checked out resource to the end of the resource list.
+// If no free resource of a dynamic type is left, a new one is created
+// to be acquired later.
 // In: rtype - name of the target resource
 //     state - current state of the requested resource
 //     dest - destination state of the requested resource
 //     owner - requester of the resource
 // Out: A valid Resource object on success, or
-//      ResourceNotFound error if target type resource does not exist in target state.
+//      ResourceNotFound error if target type resource does not exist in target state, or
+//      QuotaExceeded error if the owner holds as many resources as its quota allows.
 func (r *Ranch) Acquire(rType, state, dest, owner string) (*common.Resource, error) {
+	return r.AcquireWithPriority(rType, state, dest, owner, "", 0)
+}
+
+// AcquireWithPriority is Acquire for requests that are retried until they
+// succeed. Requests waiting for resources of a type are served in order of
+// priority, and of their first attempt for equal priorities.
+// In: requestID - identifies the attempts of a request, requests without
+//                 an ID are served after waiting requests of equal priority
+//     priority - priority of the request, higher priorities are served first
+func (r *Ranch) AcquireWithPriority(rType, state, dest, owner, requestID string, priority int) (*common.Resource, error) {
 	r.resourcesLock.Lock()
 	defer r.resourcesLock.Unlock()
 
@@ -106,20 +131,35 @@ func (r *Ranch) Acquire(rType, state, dest, owner string) (*common.Resource, err
 		logrus.WithError(err).Errorf("could not get resources")
 		return nil, &ResourceNotFound{rType}
 	}
+	if err := r.checkQuotas(rType, owner, resources); err != nil {
+		return nil, err
+	}
 
+	now := r.UpdateTime()
+	req, ahead := r.requests.update(rType, state, requestID, priority, now)
 	for idx := range resources {
 		res := resources[idx]
 		if rType == res.Type && state == res.State && res.Owner == "" {
-			res.LastUpdate = r.UpdateTime()
+			// leave the resource to a request that is served first
+			if ahead > 0 {
+				ahead--
+				continue
+			}
+			res.LastUpdate = now
 			res.Owner = owner
 			res.State = dest
 			if err := r.Storage.UpdateResource(res); err != nil {
 				logrus.WithError(err).Errorf("could not update resource %s", res.Name)
 				return nil, err
 			}
+			r.requests.remove(rType, state, requestID)
+			observeAcquired(req, rType, now)
 			return &res, nil
 		}
 	}
+	if state == common.Free {
+		r.provisionIfNeeded(rType, resources)
+	}
 	return nil, &ResourceNotFound{rType}
 }
 
@@ -299,15 +339,36 @@ func (r *Ranch) LogStatus() {
 	logrus.Infof("Current Resources : %v", string(resJSON))
 }
 
-// SyncConfig updates resource list from a file
+// SyncConfig updates resource list, lifecycle hooks and dynamic resource
+// types from a file
 func (r *Ranch) SyncConfig(config string) error {
-	resources, err := ParseConfig(config)
+	data, err := LoadConfig(config)
+	if err != nil {
+		return err
+	}
+	var resources []common.Resource
+	for _, entry := range data.Resources {
+		resources = append(resources, common.NewResourcesFromConfig(entry)...)
+	}
+
+	r.resourcesLock.Lock()
+	defer r.resourcesLock.Unlock()
+	r.SetDynamicResources(data.DynamicResources)
+	// keep the resources created for dynamic types
+	existing, err := r.Storage.GetResources()
 	if err != nil {
 		return err
 	}
+	for _, res := range existing {
+		if r.isDynamic(res.Type) {
+			resources = append(resources, res)
+		}
+	}
 	if err := r.Storage.SyncResources(resources); err != nil {
 		return err
 	}
+	r.SetHooks(data.Hooks)
+	r.SetQuotas(data.Quotas)
 	return nil
 }
 
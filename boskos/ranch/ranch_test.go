@@ -17,6 +17,8 @@ limitations under the License.
 package ranch
 
 import (
+	"io/ioutil"
+	"os"
 	"reflect"
 	"sort"
 	"testing"
@@ -640,3 +642,36 @@ func TestSyncResources(t *testing.T) {
 		}
 	}
 }
+
+func TestNewStorageKeepsPersistedState(t *testing.T) {
+	file, err := ioutil.TempFile("", "boskos-storage")
+	if err != nil {
+		t.Fatalf("failed to create storage file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(`{"Resources": [{"name": "res", "type": "t", "state": "free"}, {"name": "new", "type": "t", "state": "free"}]}`); err != nil {
+		t.Fatalf("failed to write storage file: %v", err)
+	}
+	file.Close()
+
+	rs := crds.NewCRDStorage(crds.NewTestResourceClient())
+	persisted := common.Resource{Name: "res", Type: "t", State: common.Busy, Owner: "o"}
+	if err := rs.Add(persisted); err != nil {
+		t.Fatalf("failed to add resource: %v", err)
+	}
+	s, err := NewStorage(rs, file.Name())
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+
+	res, err := s.GetResource("res")
+	if err != nil {
+		t.Fatalf("failed to get resource: %v", err)
+	}
+	if res.State != common.Busy || res.Owner != "o" {
+		t.Errorf("expected persisted state to be kept, got %v", res)
+	}
+	if _, err := s.GetResource("new"); err != nil {
+		t.Errorf("expected resource missing from the persisted state to be added: %v", err)
+	}
+}
@@ -59,10 +59,26 @@ func NewStorage(r storage.PersistenceLayer, storage string) (*Storage, error) {
 			return nil, err
 		}
 
+		// The persistence layer is authoritative, the file only seeds
+		// resources it does not know about yet.
+		existing, err := s.GetResources()
+		if err != nil {
+			return nil, err
+		}
+		known := map[string]bool{}
+		for _, res := range existing {
+			known[res.Name] = true
+		}
+
 		logrus.Info("Before adding resource loop")
 		for _, res := range data.Resources {
+			if known[res.Name] {
+				logrus.Infof("Keeping persisted state of resource %s.", res.Name)
+				continue
+			}
 			if err := s.AddResource(res); err != nil {
 				logrus.WithError(err).Errorf("Failed Adding Resources: %s - %s.", res.Name, res.State)
+				continue
 			}
 			logrus.Infof("Successfully Added Resources: %s - %s.", res.Name, res.State)
 		}
@@ -190,9 +206,8 @@ func (s *Storage) SyncResources(data []common.Resource) error {
 	return finalError
 }
 
-// ParseConfig reads in configPath and returns a list of resource objects
-// on success.
-func ParseConfig(configPath string) ([]common.Resource, error) {
+// LoadConfig reads in and validates the boskos config at configPath.
+func LoadConfig(configPath string) (*common.BoskosConfig, error) {
 	file, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		return nil, err
@@ -203,6 +218,25 @@ func ParseConfig(configPath string) ([]common.Resource, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := validateHooks(data.Hooks); err != nil {
+		return nil, err
+	}
+	if err := validateDynamicResources(&data); err != nil {
+		return nil, err
+	}
+	if err := validateQuotas(data.Quotas); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// ParseConfig reads in configPath and returns a list of resource objects
+// on success.
+func ParseConfig(configPath string) ([]common.Resource, error) {
+	data, err := LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
 
 	var resources []common.Resource
 	for _, entry := range data.Resources {
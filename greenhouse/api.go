@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/greenhouse/diskcache"
+)
+
+// lookups counts the hits and misses of cache lookups
+type lookups struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// lookupStats tracks lookups per workspace cache since the last server start
+type lookupStats struct {
+	lock   sync.Mutex
+	caches map[string]*lookups
+}
+
+func newLookupStats() *lookupStats {
+	return &lookupStats{caches: map[string]*lookups{}}
+}
+
+func (s *lookupStats) record(key string, hit bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	id := cacheIDFromKey(key)
+	if _, ok := s.caches[id]; !ok {
+		s.caches[id] = &lookups{}
+	}
+	if hit {
+		s.caches[id].Hits++
+	} else {
+		s.caches[id].Misses++
+	}
+}
+
+// forPrefix sums the lookups of the workspace caches starting with prefix
+func (s *lookupStats) forPrefix(prefix string) lookups {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var sum lookups
+	for id, l := range s.caches {
+		if strings.HasPrefix(id, prefix) {
+			sum.Hits += l.Hits
+			sum.Misses += l.Misses
+		}
+	}
+	return sum
+}
+
+// forget drops the lookups of the workspace caches starting with prefix
+func (s *lookupStats) forget(prefix string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for id := range s.caches {
+		if strings.HasPrefix(id, prefix) {
+			delete(s.caches, id)
+		}
+	}
+}
+
+// prefixStats is served by /stats
+type prefixStats struct {
+	Prefix string `json:"prefix"`
+	// Entries is the number of keys starting with Prefix
+	Entries int `json:"entries"`
+	// Bytes is the size of all entries, counting contents shared between keys once per key
+	Bytes int64 `json:"bytes"`
+	lookups
+	// HitRate is the share of lookups that were hits, 0 without lookups
+	HitRate float64 `json:"hit_rate"`
+}
+
+// invalidation is served by /invalidate
+type invalidation struct {
+	Prefix  string `json:"prefix"`
+	Deleted int    `json:"deleted"`
+	Failed  int    `json:"failed"`
+}
+
+// keyPrefix returns the prefix query parameter without leading slash
+func keyPrefix(r *http.Request) string {
+	return strings.TrimPrefix(r.URL.Query().Get("prefix"), "/")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.WithError(err).Error("Failed to write response.")
+	}
+}
+
+// statsHandler serves the size and hit rate of the entries with keys
+// starting with the prefix query parameter, e.g. GET /stats?prefix=org/repo
+func statsHandler(cache *diskcache.Cache, stats *lookupStats) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+			return
+		}
+		prefix := keyPrefix(r)
+		result := prefixStats{Prefix: prefix, lookups: stats.forPrefix(prefix)}
+		for _, entry := range cache.GetEntriesWithPrefix(prefix) {
+			result.Entries++
+			result.Bytes += entry.Size
+		}
+		if total := result.Hits + result.Misses; total > 0 {
+			result.HitRate = float64(result.Hits) / float64(total)
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+}
+
+// invalidateHandler deletes all entries with keys starting with the prefix
+// query parameter, e.g. POST /invalidate?prefix=org/repo,toolchain-hash/
+func invalidateHandler(cache *diskcache.Cache, stats *lookupStats) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+			return
+		}
+		prefix := keyPrefix(r)
+		if prefix == "" {
+			http.Error(w, "a prefix is required, refusing to invalidate the whole cache", http.StatusBadRequest)
+			return
+		}
+		logger := logrus.WithField("prefix", prefix)
+		result := invalidation{Prefix: prefix}
+		for _, entry := range cache.GetEntriesWithPrefix(prefix) {
+			if err := cache.Delete(cache.PathToKey(entry.Path)); err != nil {
+				logger.WithError(err).Errorf("Failed to invalidate entry at path: %v", entry.Path)
+				result.Failed++
+				continue
+			}
+			result.Deleted++
+		}
+		stats.forget(prefix)
+		logger.Infof("Invalidated %d entries.", result.Deleted)
+		status := http.StatusOK
+		if result.Failed > 0 {
+			status = http.StatusInternalServerError
+		}
+		writeJSON(w, status, result)
+	})
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/test-infra/greenhouse/diskcache"
+)
+
+func TestStatsAndInvalidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "greenhouse-api")
+	if err != nil {
+		t.Fatalf("failed to create cache dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cache := diskcache.NewCache(dir)
+	stats := newLookupStats()
+	server := httptest.NewServer(cacheHandler(cache, sets.NewString(), stats))
+	defer server.Close()
+
+	request := func(method, path string, body []byte) int {
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to %s %s: %v", method, path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	request(http.MethodPut, "/org/repo,a/ac/1", []byte("poisoned"))
+	request(http.MethodPut, "/org/repo,b/ac/1", []byte("fine"))
+	request(http.MethodGet, "/org/repo,a/ac/1", nil)
+	request(http.MethodGet, "/org/repo,a/ac/2", nil)
+	request(http.MethodGet, "/org/repo,b/ac/1", nil)
+
+	getStats := func(prefix string) prefixStats {
+		rec := httptest.NewRecorder()
+		statsHandler(cache, stats).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats?prefix="+prefix, nil))
+		var result prefixStats
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("failed to decode stats: %v", err)
+		}
+		return result
+	}
+	expected := prefixStats{Prefix: "org/repo,a", Entries: 1, Bytes: int64(len("poisoned")), lookups: lookups{Hits: 1, Misses: 1}, HitRate: 0.5}
+	if actual := getStats("/org/repo,a"); actual != expected {
+		t.Errorf("expected stats %+v, got %+v", expected, actual)
+	}
+	if actual := getStats("org/repo"); actual.Entries != 2 || actual.Hits != 2 || actual.Misses != 1 {
+		t.Errorf("expected stats of both caches of the repo, got %+v", actual)
+	}
+
+	rec := httptest.NewRecorder()
+	invalidateHandler(cache, stats).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/invalidate", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected invalidating without prefix to be refused, got status %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	invalidateHandler(cache, stats).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/invalidate?prefix=org/repo,a/", nil))
+	var result invalidation
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode invalidation: %v", err)
+	}
+	if expected := (invalidation{Prefix: "org/repo,a/", Deleted: 1}); rec.Code != http.StatusOK || result != expected {
+		t.Errorf("expected invalidation %+v, got status %d and %+v", expected, rec.Code, result)
+	}
+
+	if status := request(http.MethodGet, "/org/repo,a/ac/1", nil); status != http.StatusNotFound {
+		t.Errorf("expected invalidated entry to be gone, got status %d", status)
+	}
+	if status := request(http.MethodGet, "/org/repo,b/ac/1", nil); status != http.StatusOK {
+		t.Errorf("expected other entries to be kept, got status %d", status)
+	}
+}
@@ -26,6 +26,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"k8s.io/test-infra/greenhouse/diskutil"
@@ -33,6 +35,14 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// blobsDir holds the contents of all entries by their SHA256, entries are
+	// hard links to these blobs so equal contents are only stored once
+	blobsDir = ".blobs"
+	// retainedDir holds an empty marker file for each retained entry
+	retainedDir = ".retained"
+)
+
 // ReadHandler should be implemented by cache users for use with Cache.Get
 type ReadHandler func(exists bool, contents io.ReadSeeker) error
 
@@ -40,6 +50,8 @@ type ReadHandler func(exists bool, contents io.ReadSeeker) error
 type Cache struct {
 	diskRoot string
 	logger   *logrus.Entry
+	// number of puts whose contents were already stored under another key
+	deduplicated uint64
 }
 
 // NewCache returns a new Cache given the root directory that should be used
@@ -87,9 +99,20 @@ func removeTemp(path string) {
 	}
 }
 
+// blobPath returns the path of the blob holding contents with the given hash
+func (c *Cache) blobPath(contentSHA256 string) string {
+	return filepath.Join(c.diskRoot, blobsDir, contentSHA256[:2], contentSHA256)
+}
+
+// markerPath returns the path of the file marking the key as retained
+func (c *Cache) markerPath(key string) string {
+	return filepath.Join(c.diskRoot, retainedDir, key)
+}
+
 // Put copies the content reader until the end into the cache at key
 // if contentSHA256 is not "" then the contents will only be stored in the
 // cache if the content's hex string SHA256 matches
+// Contents already stored under another key are shared with it on disk.
 func (c *Cache) Put(key string, content io.Reader, contentSHA256 string) error {
 	// make sure directory exists
 	path := c.KeyToPath(key)
@@ -98,52 +121,91 @@ func (c *Cache) Put(key string, content io.Reader, contentSHA256 string) error {
 	if err != nil {
 		logrus.WithError(err).Errorf("error ensuring directory '%s' exists", dir)
 	}
+	blobs := filepath.Join(c.diskRoot, blobsDir)
+	if err := ensureDir(blobs); err != nil {
+		return fmt.Errorf("failed to create blob directory: %v", err)
+	}
 
 	// create a temp file to get the content on disk
-	temp, err := ioutil.TempFile(dir, "temp-put")
+	temp, err := ioutil.TempFile(blobs, "temp-put")
 	if err != nil {
 		return fmt.Errorf("failed to create cache entry: %v", err)
 	}
 
-	// fast path copying when not hashing content,s
-	if contentSHA256 == "" {
-		_, err = io.Copy(temp, content)
-		if err != nil {
-			removeTemp(temp.Name())
-			return fmt.Errorf("failed to copy into cache entry: %v", err)
-		}
+	// always hash the content, the hash addresses the blob holding it
+	hasher := sha256.New()
+	_, err = io.Copy(io.MultiWriter(temp, hasher), content)
+	if err != nil {
+		temp.Close()
+		removeTemp(temp.Name())
+		return fmt.Errorf("failed to copy into cache entry: %v", err)
+	}
+	actualContentSHA256 := hex.EncodeToString(hasher.Sum(nil))
+	if contentSHA256 != "" && actualContentSHA256 != contentSHA256 {
+		temp.Close()
+		removeTemp(temp.Name())
+		return fmt.Errorf(
+			"hashes did not match for '%s', given: '%s' actual: '%s",
+			key, contentSHA256, actualContentSHA256)
+	}
 
+	err = temp.Sync()
+	if err != nil {
+		temp.Close()
+		removeTemp(temp.Name())
+		return fmt.Errorf("failed to sync cache entry: %v", err)
+	}
+	temp.Close()
+
+	// move the content to its blob, unless it is already stored
+	blob := c.blobPath(actualContentSHA256)
+	if exists(blob) {
+		removeTemp(temp.Name())
+		atomic.AddUint64(&c.deduplicated, 1)
 	} else {
-		hasher := sha256.New()
-		_, err = io.Copy(io.MultiWriter(temp, hasher), content)
-		if err != nil {
+		if err := ensureDir(filepath.Dir(blob)); err != nil {
 			removeTemp(temp.Name())
-			return fmt.Errorf("failed to copy into cache entry: %v", err)
+			return fmt.Errorf("failed to create blob directory: %v", err)
 		}
-		actualContentSHA256 := hex.EncodeToString(hasher.Sum(nil))
-		if actualContentSHA256 != contentSHA256 {
+		if err := os.Rename(temp.Name(), blob); err != nil {
 			removeTemp(temp.Name())
-			return fmt.Errorf(
-				"hashes did not match for '%s', given: '%s' actual: '%s",
-				key, contentSHA256, actualContentSHA256)
+			return fmt.Errorf("failed to store blob: %v", err)
 		}
 	}
 
-	// move the content to the key location
-	err = temp.Sync()
-	if err != nil {
-		removeTemp(temp.Name())
-		return fmt.Errorf("failed to sync cache entry: %v", err)
+	// link the blob to the key location, replacing any previous entry atomically
+	link := temp.Name()
+	if err := os.Link(blob, link); err != nil {
+		return fmt.Errorf("failed to link blob: %v", err)
 	}
-	temp.Close()
-	err = os.Rename(temp.Name(), path)
+	err = os.Rename(link, path)
 	if err != nil {
-		removeTemp(temp.Name())
+		removeTemp(link)
 		return fmt.Errorf("failed to insert contents into cache: %v", err)
 	}
 	return nil
 }
 
+// Deduplicated returns the number of puts whose contents were already stored
+// under another key
+func (c *Cache) Deduplicated() uint64 {
+	return atomic.LoadUint64(&c.deduplicated)
+}
+
+// SetRetained marks the entry at key as retained, retained entries report so
+// in their EntryInfo until they are deleted
+func (c *Cache) SetRetained(key string) error {
+	marker := c.markerPath(key)
+	if err := ensureDir(filepath.Dir(marker)); err != nil {
+		return fmt.Errorf("failed to create marker directory: %v", err)
+	}
+	f, err := os.Create(marker)
+	if err != nil {
+		return fmt.Errorf("failed to mark entry as retained: %v", err)
+	}
+	return f.Close()
+}
+
 // Get provides your readHandler with the contents at key
 func (c *Cache) Get(key string, readHandler ReadHandler) error {
 	path := c.KeyToPath(key)
@@ -161,33 +223,117 @@ func (c *Cache) Get(key string, readHandler ReadHandler) error {
 type EntryInfo struct {
 	Path       string
 	LastAccess time.Time
+	// Size of the contents, which may be shared with other entries
+	Size int64
+	// Retained is true for entries marked with SetRetained
+	Retained bool
 }
 
 // GetEntries walks the cache dir and returns all paths that exist
 // In the future this *may* be made smarter
 func (c *Cache) GetEntries() []EntryInfo {
+	return c.walkEntries(c.diskRoot, "")
+}
+
+// GetEntriesWithPrefix returns the entries whose keys start with prefix
+func (c *Cache) GetEntriesWithPrefix(prefix string) []EntryInfo {
+	prefix = strings.TrimPrefix(prefix, "/")
+	// only walk the directory that can contain matching keys
+	start := c.KeyToPath(prefix)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		start = filepath.Dir(start)
+	}
+	if start != c.diskRoot && !strings.HasPrefix(start, c.diskRoot+string(os.PathSeparator)) || !exists(start) {
+		return []EntryInfo{}
+	}
+	return c.walkEntries(start, prefix)
+}
+
+func (c *Cache) walkEntries(start, prefix string) []EntryInfo {
 	entries := []EntryInfo{}
 	// note we swallow errors because we just need to know what keys exist
 	// some keys missing is OK since this is used for eviction, but not returning
 	// any of the keys due to some error is NOT
-	_ = filepath.Walk(c.diskRoot, func(path string, f os.FileInfo, err error) error {
+	_ = filepath.Walk(start, func(path string, f os.FileInfo, err error) error {
 		if err != nil {
 			logrus.WithError(err).Error("error getting some entries")
 			return nil
 		}
-		if !f.IsDir() {
-			atime := diskutil.GetATime(path, time.Now())
-			entries = append(entries, EntryInfo{
-				Path:       path,
-				LastAccess: atime,
-			})
+		if f.IsDir() {
+			// blobs and markers are not entries themselves
+			if path == filepath.Join(c.diskRoot, blobsDir) || path == filepath.Join(c.diskRoot, retainedDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		key := c.PathToKey(path)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
 		}
+		atime := diskutil.GetATime(path, time.Now())
+		entries = append(entries, EntryInfo{
+			Path:       path,
+			LastAccess: atime,
+			Size:       f.Size(),
+			Retained:   exists(c.markerPath(key)),
+		})
 		return nil
 	})
 	return entries
 }
 
-// Delete deletes the file at key
+// Delete deletes the file at key, and its contents once no other key
+// shares them
 func (c *Cache) Delete(key string) error {
-	return os.Remove(c.KeyToPath(key))
+	path := c.KeyToPath(key)
+	blob := c.orphanedBlob(path)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if err := os.Remove(c.markerPath(key)); err != nil && !os.IsNotExist(err) {
+		logrus.WithError(err).Errorf("Failed to remove retention marker of %v", key)
+	}
+	if blob != "" && linkCount(blob) == 1 {
+		if err := os.Remove(blob); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove blob: %v", err)
+		}
+	}
+	return nil
+}
+
+// orphanedBlob returns the blob of the entry at path if the entry is the only
+// key linked to it, or "" otherwise
+func (c *Cache) orphanedBlob(path string) string {
+	info, err := os.Stat(path)
+	if err != nil || linkCount(path) != 2 {
+		return ""
+	}
+	// CAS keys end with the hash of their contents, which saves hashing them
+	if name := filepath.Base(path); len(name) == sha256.Size*2 {
+		if blobInfo, err := os.Stat(c.blobPath(name)); err == nil && os.SameFile(info, blobInfo) {
+			return c.blobPath(name)
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return ""
+	}
+	return c.blobPath(hex.EncodeToString(hasher.Sum(nil)))
+}
+
+// linkCount returns the number of hard links to the file at path
+func linkCount(path string) uint64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Nlink)
+	}
+	return 0
 }
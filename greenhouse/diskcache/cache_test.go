@@ -207,3 +207,129 @@ func TestCacheStorage(t *testing.T) {
 		t.Fatalf("cache.GetEntries() should be empty after deleting all keys, got: %v", entries)
 	}
 }
+
+// test that equal contents are stored once and survive deleting other keys
+func TestCacheDeduplication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-tests")
+	if err != nil {
+		t.Fatalf("Failed to create tempdir for tests! %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewCache(dir)
+
+	contents := []byte{1, 3, 3, 7}
+	hash := hashBytes(contents)
+	keys := []string{"org/repo,a/cas/" + hash, "org/other,b/cas/" + hash, "org/repo,a/ac/action"}
+	for i, key := range keys {
+		if err := cache.Put(key, bytes.NewReader(contents), ""); err != nil {
+			t.Fatalf("Failed to put %s: %v", key, err)
+		}
+		if deduplicated := cache.Deduplicated(); deduplicated != uint64(i) {
+			t.Errorf("Expected %d deduplicated puts after putting %s, got %d", i, key, deduplicated)
+		}
+	}
+	if links := linkCount(cache.blobPath(hash)); links != uint64(len(keys)+1) {
+		t.Errorf("Expected blob to be linked by all %d keys, got %d links", len(keys), links)
+	}
+
+	for i, key := range keys {
+		if err := cache.Delete(key); err != nil {
+			t.Fatalf("Failed to delete %s: %v", key, err)
+		}
+		if last := i == len(keys)-1; exists(cache.blobPath(hash)) == last {
+			t.Errorf("Expected blob to exist only while keys share it, exists after deleting %d keys: %v", i+1, !last)
+		}
+		for _, other := range keys[i+1:] {
+			err := cache.Get(other, func(exists bool, contents io.ReadSeeker) error {
+				if !exists {
+					t.Errorf("Expected %s to exist after deleting %s", other, key)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Failed to get %s: %v", other, err)
+			}
+		}
+	}
+}
+
+// test that retained entries are reported until they are deleted
+func TestCacheRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-tests")
+	if err != nil {
+		t.Fatalf("Failed to create tempdir for tests! %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewCache(dir)
+
+	for _, key := range []string{"org/repo/ac/retained", "org/repo/ac/other"} {
+		if err := cache.Put(key, bytes.NewReader([]byte(key)), ""); err != nil {
+			t.Fatalf("Failed to put %s: %v", key, err)
+		}
+	}
+	if err := cache.SetRetained("org/repo/ac/retained"); err != nil {
+		t.Fatalf("Failed to retain entry: %v", err)
+	}
+
+	retained := sets.NewString()
+	entries := cache.GetEntries()
+	for _, entry := range entries {
+		if entry.Retained {
+			retained.Insert(cache.PathToKey(entry.Path))
+		}
+	}
+	if len(entries) != 2 || !retained.Equal(sets.NewString("org/repo/ac/retained")) {
+		t.Errorf("Expected only org/repo/ac/retained of two entries to be retained, got %v of %v", retained.List(), entries)
+	}
+
+	if err := cache.Delete("org/repo/ac/retained"); err != nil {
+		t.Fatalf("Failed to delete entry: %v", err)
+	}
+	if err := cache.Put("org/repo/ac/retained", bytes.NewReader([]byte{1}), ""); err != nil {
+		t.Fatalf("Failed to put entry again: %v", err)
+	}
+	for _, entry := range cache.GetEntries() {
+		if entry.Retained {
+			t.Errorf("Expected deleting %s to drop its retention", cache.PathToKey(entry.Path))
+		}
+	}
+}
+
+// test listing entries by key prefix
+func TestGetEntriesWithPrefix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-tests")
+	if err != nil {
+		t.Fatalf("Failed to create tempdir for tests! %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewCache(dir)
+
+	for _, key := range []string{"org/repo,a/ac/1", "org/repo,b/cas/2", "org/repository,a/ac/3", "other/repo,a/ac/4"} {
+		if err := cache.Put(key, bytes.NewReader([]byte(key)), ""); err != nil {
+			t.Fatalf("Failed to put %s: %v", key, err)
+		}
+	}
+	testCases := []struct {
+		prefix   string
+		expected sets.String
+	}{
+		{prefix: "org/repo,", expected: sets.NewString("org/repo,a/ac/1", "org/repo,b/cas/2")},
+		{prefix: "/org/repo", expected: sets.NewString("org/repo,a/ac/1", "org/repo,b/cas/2", "org/repository,a/ac/3")},
+		{prefix: "org/repo,a/", expected: sets.NewString("org/repo,a/ac/1")},
+		{prefix: "missing/", expected: sets.NewString()},
+		{prefix: "../", expected: sets.NewString()},
+		{prefix: "", expected: sets.NewString("org/repo,a/ac/1", "org/repo,b/cas/2", "org/repository,a/ac/3", "other/repo,a/ac/4")},
+	}
+	for _, tc := range testCases {
+		keys := sets.NewString()
+		for _, entry := range cache.GetEntriesWithPrefix(tc.prefix) {
+			keys.Insert(cache.PathToKey(entry.Path))
+			if entry.Size != int64(len(cache.PathToKey(entry.Path))) {
+				t.Errorf("Expected size of %s to be its length, got %d", entry.Path, entry.Size)
+			}
+		}
+		if !keys.Equal(tc.expected) {
+			t.Errorf("Expected entries with prefix %q to be %v, got %v", tc.prefix, tc.expected.List(), keys.List())
+		}
+	}
+}
@@ -25,6 +25,18 @@ import (
 	"k8s.io/test-infra/greenhouse/diskutil"
 )
 
+// sortForEviction orders entries to evict least recently used entries first.
+// If retainBranches is set, entries of retained branches are only evicted once
+// all other entries are gone.
+func sortForEviction(entries []diskcache.EntryInfo, retainBranches bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if retainBranches && entries[i].Retained != entries[j].Retained {
+			return entries[j].Retained
+		}
+		return entries[i].LastAccess.Before(entries[j].LastAccess)
+	})
+}
+
 // monitorDiskAndEvict loops monitoring the disk, evicting cache entries
 // when the disk passes either minPercentBlocksFree until the disk is above
 // evictUntilPercentBlocksFree
@@ -32,6 +44,7 @@ func monitorDiskAndEvict(
 	c *diskcache.Cache,
 	interval time.Duration,
 	minPercentBlocksFree, evictUntilPercentBlocksFree float64,
+	retainBranches bool,
 ) {
 	diskRoot := c.DiskRoot()
 	// forever check if usage is past thresholds and evict
@@ -50,12 +63,10 @@ func monitorDiskAndEvict(
 		// if we are past the threshold, start evicting
 		if blocksFree < minPercentBlocksFree {
 			logger.Warn("Eviction triggered")
-			// get all cache entries and sort by lastaccess
+			// get all cache entries and sort by eviction order
 			// so we can pop entries until we have evicted enough
 			files := c.GetEntries()
-			sort.Slice(files, func(i, j int) bool {
-				return files[i].LastAccess.Before(files[j].LastAccess)
-			})
+			sortForEviction(files, retainBranches)
 			// evict until we pass the safe threshold so we don't thrash at the eviction trigger
 			for blocksFree < evictUntilPercentBlocksFree {
 				if len(files) < 1 {
@@ -69,6 +80,9 @@ func monitorDiskAndEvict(
 					logger.WithError(err).Errorf("Error deleting entry at path: %v", entry.Path)
 				} else {
 					promMetrics.FilesEvicted.Inc()
+					if entry.Retained {
+						promMetrics.RetainedFilesEvicted.Inc()
+					}
 					promMetrics.LastEvictedAccessAge.Set(time.Now().Sub(entry.LastAccess).Hours())
 				}
 				// get new disk usage
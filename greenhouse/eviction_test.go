@@ -0,0 +1,63 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/test-infra/greenhouse/diskcache"
+)
+
+func TestSortForEviction(t *testing.T) {
+	now := time.Now()
+	entries := []diskcache.EntryInfo{
+		{Path: "retained-old", LastAccess: now.Add(-3 * time.Hour), Retained: true},
+		{Path: "new", LastAccess: now},
+		{Path: "retained-new", LastAccess: now.Add(-time.Minute), Retained: true},
+		{Path: "old", LastAccess: now.Add(-2 * time.Hour)},
+	}
+	testCases := []struct {
+		name           string
+		retainBranches bool
+		expected       []string
+	}{
+		{
+			name:     "plain LRU",
+			expected: []string{"retained-old", "old", "retained-new", "new"},
+		},
+		{
+			name:           "retained branches last",
+			retainBranches: true,
+			expected:       []string{"old", "new", "retained-old", "retained-new"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sorted := append([]diskcache.EntryInfo{}, entries...)
+			sortForEviction(sorted, tc.retainBranches)
+			var paths []string
+			for _, entry := range sorted {
+				paths = append(paths, entry.Path)
+			}
+			if !reflect.DeepEqual(paths, tc.expected) {
+				t.Errorf("expected eviction order %v, got %v", tc.expected, paths)
+			}
+		})
+	}
+}
@@ -23,6 +23,10 @@ limitations under the License.
 //
 // nursery assumes you are using SHA256
 //
+// requests may be prefixed with /_branch/<url-escaped branch>/ by jobs
+// populating the cache for a branch, entries put by jobs of the branches in
+// --retained-branches are evicted after all other entries
+//
 // [1] https://docs.bazel.build/versions/master/remote-caching.html
 // [2] https://docs.bazel.build/versions/master/remote-caching.html#http-caching-protocol
 package main
@@ -33,6 +37,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -43,6 +48,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 var dir = flag.String("dir", "", "location to store cache entries on disk")
@@ -59,6 +65,8 @@ var evictUntilPercentBlocksFree = flag.Float64("evict-until-percent-blocks-free"
 	"continue evicting from the cache until at least this percent of blocks are free")
 var diskCheckInterval = flag.Duration("disk-check-interval", time.Second*10,
 	"interval between checking disk usage (and potentially evicting entries)")
+var retainedBranches = flag.String("retained-branches", "",
+	"comma separated branches whose entries are only evicted after all other entries, plain LRU eviction if empty")
 
 // global metrics object, see prometheus.go
 var promMetrics *prometheusMetrics
@@ -77,17 +85,29 @@ func main() {
 		logrus.Fatal("--dir must be set!")
 	}
 
+	retained := sets.NewString()
+	for _, branch := range strings.Split(*retainedBranches, ",") {
+		if branch = strings.TrimSpace(branch); branch != "" {
+			retained.Insert(branch)
+		}
+	}
+
 	cache := diskcache.NewCache(*dir)
+	registerCacheMetrics(cache)
 	go monitorDiskAndEvict(
 		cache, *diskCheckInterval,
 		*minPercentBlocksFree, *evictUntilPercentBlocksFree,
+		retained.Len() > 0,
 	)
 
 	go updateMetrics(*metricsUpdateInterval, cache.DiskRoot())
+	stats := newLookupStats()
 
-	// listen for prometheus scraping
+	// listen for prometheus scraping and cache administration
 	metricsMux := http.NewServeMux()
 	metricsMux.Handle("/prometheus", promhttp.Handler())
+	metricsMux.Handle("/stats", statsHandler(cache, stats))
+	metricsMux.Handle("/invalidate", invalidateHandler(cache, stats))
 	metricsAddr := fmt.Sprintf("%s:%d", *host, *metricsPort)
 	go func() {
 		logrus.Infof("Metrics Listening on: %s", metricsAddr)
@@ -98,7 +118,7 @@ func main() {
 
 	// listen for cache requests
 	cacheMux := http.NewServeMux()
-	cacheMux.Handle("/", cacheHandler(cache))
+	cacheMux.Handle("/", cacheHandler(cache, retained, stats))
 	cacheAddr := fmt.Sprintf("%s:%d", *host, *cachePort)
 	logrus.Infof("Cache Listening on: %s", cacheAddr)
 	logrus.WithField("mux", "cache").WithError(
@@ -109,16 +129,61 @@ func main() {
 // file not found error, used below
 var errNotFound = errors.New("entry not found")
 
-func cacheHandler(cache *diskcache.Cache) http.Handler {
+// branchPrefix starts the path of requests by jobs populating the cache for a branch
+const branchPrefix = "/_branch/"
+
+// parsePath splits the request path into the branch it populates the cache
+// for, if any, and the cache key
+func parsePath(u *url.URL) (branch, key string, err error) {
+	escaped := u.EscapedPath()
+	if !strings.HasPrefix(escaped, branchPrefix) {
+		return "", u.Path, nil
+	}
+	parts := strings.SplitN(strings.TrimPrefix(escaped, branchPrefix), "/", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("expected %s<branch>/<key>", branchPrefix)
+	}
+	if branch, err = url.PathUnescape(parts[0]); err != nil {
+		return "", "", err
+	}
+	if key, err = url.PathUnescape("/" + parts[1]); err != nil {
+		return "", "", err
+	}
+	return branch, key, nil
+}
+
+// cacheIDFromKey returns the workspace cache holding the key, i.e. all
+// segments but the last two
+func cacheIDFromKey(key string) string {
+	parts := strings.Split(strings.TrimPrefix(key, "/"), "/")
+	if len(parts) < 3 {
+		return ""
+	}
+	return strings.Join(parts[:len(parts)-2], "/")
+}
+
+// repoFromKey returns the repo of the workspace cache holding the key, i.e.
+// its ID up to the first comma
+func repoFromKey(key string) string {
+	return strings.SplitN(cacheIDFromKey(key), ",", 2)[0]
+}
+
+func cacheHandler(cache *diskcache.Cache, retainedBranches sets.String, stats *lookupStats) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		logger := logrus.WithFields(logrus.Fields{
 			"method": r.Method,
 			"path":   r.URL.Path,
 		})
+		branch, key, err := parsePath(r.URL)
+		if err != nil {
+			logger.WithError(err).Warn("received an invalid request")
+			http.Error(w, "invalid location", http.StatusBadRequest)
+			return
+		}
 		// parse and validate path
 		// the last segment should be a hash, and
 		// the second to last segment should be "ac" or "cas"
-		parts := strings.Split(r.URL.Path, "/")
+		parts := strings.Split(key, "/")
 		if len(parts) < 3 {
 			logger.Warn("received an invalid request")
 			http.Error(w, "invalid location", http.StatusBadRequest)
@@ -132,12 +197,13 @@ func cacheHandler(cache *diskcache.Cache) http.Handler {
 			return
 		}
 		requestingAction := acOrCAS == "ac"
+		repo := repoFromKey(key)
 
 		// actually handle request depending on method
 		switch m := r.Method; m {
 		// handle retrieval
 		case http.MethodGet:
-			err := cache.Get(r.URL.Path, func(exists bool, contents io.ReadSeeker) error {
+			err := cache.Get(key, func(exists bool, contents io.ReadSeeker) error {
 				if !exists {
 					return errNotFound
 				}
@@ -152,6 +218,8 @@ func cacheHandler(cache *diskcache.Cache) http.Handler {
 					} else {
 						promMetrics.CASMisses.Inc()
 					}
+					promMetrics.RepoRequests.WithLabelValues(repo, acOrCAS, "miss").Inc()
+					stats.record(key, false)
 					http.Error(w, err.Error(), http.StatusNotFound)
 					return
 				}
@@ -166,6 +234,8 @@ func cacheHandler(cache *diskcache.Cache) http.Handler {
 			} else {
 				promMetrics.CASHits.Inc()
 			}
+			promMetrics.RepoRequests.WithLabelValues(repo, acOrCAS, "hit").Inc()
+			stats.record(key, true)
 
 		// handle upload
 		case http.MethodPut:
@@ -175,12 +245,17 @@ func cacheHandler(cache *diskcache.Cache) http.Handler {
 			if requestingAction {
 				hash = ""
 			}
-			err := cache.Put(r.URL.Path, r.Body, hash)
+			err := cache.Put(key, r.Body, hash)
 			if err != nil {
-				logger.WithError(err).Errorf("Failed to put: %v", r.URL.Path)
+				logger.WithError(err).Errorf("Failed to put: %v", key)
 				http.Error(w, "failed to put in cache", http.StatusInternalServerError)
 				return
 			}
+			if retainedBranches.Has(branch) {
+				if err := cache.SetRetained(key); err != nil {
+					logger.WithError(err).Errorf("Failed to retain: %v", key)
+				}
+			}
 
 		// handle unsupported methods...
 		default:
@@ -0,0 +1,86 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	testCases := []struct {
+		name           string
+		path           string
+		expectedBranch string
+		expectedKey    string
+		expectErr      bool
+	}{
+		{
+			name:        "no branch",
+			path:        "/kubernetes/test-infra,abc/cas/123",
+			expectedKey: "/kubernetes/test-infra,abc/cas/123",
+		},
+		{
+			name:           "branch",
+			path:           "/_branch/master/kubernetes/test-infra,abc/ac/123",
+			expectedBranch: "master",
+			expectedKey:    "/kubernetes/test-infra,abc/ac/123",
+		},
+		{
+			name:           "escaped branch",
+			path:           "/_branch/feature%2Fthing/kubernetes/test-infra,abc/cas/123",
+			expectedBranch: "feature/thing",
+			expectedKey:    "/kubernetes/test-infra,abc/cas/123",
+		},
+		{
+			name:      "branch without key",
+			path:      "/_branch/master",
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse("http://greenhouse:8080" + tc.path)
+			if err != nil {
+				t.Fatalf("failed to parse URL: %v", err)
+			}
+			branch, key, err := parsePath(u)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error: %v, got %v", tc.expectErr, err)
+			}
+			if branch != tc.expectedBranch || key != tc.expectedKey {
+				t.Errorf("expected branch %q and key %q, got %q and %q", tc.expectedBranch, tc.expectedKey, branch, key)
+			}
+		})
+	}
+}
+
+func TestRepoFromKey(t *testing.T) {
+	testCases := []struct {
+		key      string
+		expected string
+	}{
+		{key: "/kubernetes/test-infra,abc/cas/123", expected: "kubernetes/test-infra"},
+		{key: "/kubernetes/test-infra/ac/123", expected: "kubernetes/test-infra"},
+		{key: "/ac/123", expected: ""},
+	}
+	for _, tc := range testCases {
+		if repo := repoFromKey(tc.key); repo != tc.expected {
+			t.Errorf("expected repo of %s to be %q, got %q", tc.key, tc.expected, repo)
+		}
+	}
+}
@@ -18,6 +18,8 @@ package main
 
 import (
 	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/test-infra/greenhouse/diskcache"
 )
 
 // prometheusMetrics are served by /prometheus on the metrics port
@@ -31,6 +33,8 @@ type prometheusMetrics struct {
 	ActionCacheMisses    prometheus.Counter
 	CASMisses            prometheus.Counter
 	LastEvictedAccessAge prometheus.Gauge
+	RetainedFilesEvicted prometheus.Counter
+	RepoRequests         *prometheus.CounterVec
 }
 
 func initMetrics() *prometheusMetrics {
@@ -71,6 +75,14 @@ func initMetrics() *prometheusMetrics {
 			Name: "bazel_cache_last_evicted_access_age",
 			Help: "Hours since last access of most recently evicted file (at eviction time)",
 		}),
+		RetainedFilesEvicted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "bazel_cache_evicted_retained_files",
+			Help: "number of files of retained branches evicted since last server start",
+		}),
+		RepoRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bazel_cache_repo_requests",
+			Help: "Number of cache lookups per repo, cache (ac or cas) and result (hit or miss) since last server start",
+		}, []string{"repo", "cache", "result"}),
 	}
 	prometheus.MustRegister(metrics.DiskFree)
 	prometheus.MustRegister(metrics.DiskUsed)
@@ -81,5 +93,17 @@ func initMetrics() *prometheusMetrics {
 	prometheus.MustRegister(metrics.ActionCacheMisses)
 	prometheus.MustRegister(metrics.CASMisses)
 	prometheus.MustRegister(metrics.LastEvictedAccessAge)
+	prometheus.MustRegister(metrics.RetainedFilesEvicted)
+	prometheus.MustRegister(metrics.RepoRequests)
 	return metrics
 }
+
+// registerCacheMetrics registers metrics read from the cache itself
+func registerCacheMetrics(cache *diskcache.Cache) {
+	prometheus.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "bazel_cache_deduplicated_puts",
+		Help: "Number of uploads whose contents were already stored for another key since last server start",
+	}, func() float64 {
+		return float64(cache.Deduplicated())
+	}))
+}
@@ -86,6 +86,11 @@ make_bazel_rc () {
     cache_id="$(get_workspace),$(hash_toolchains)"
     local cache_url
     cache_url="http://${CACHE_HOST}:${CACHE_PORT}/${cache_id}"
+    # tell greenhouse which branch postsubmits populate the cache for, so it
+    # can keep their entries longer than those of presubmits
+    if [[ "${JOB_TYPE:-}" == "postsubmit" ]] && [[ -n "${PULL_BASE_REF:-}" ]]; then
+        cache_url="http://${CACHE_HOST}:${CACHE_PORT}/_branch/${PULL_BASE_REF//\//%2F}/${cache_id}"
+    fi
     echo "build --remote_http_cache=${cache_url}"
     # specifically for bazel 0.15.0 we want to set this flag
     # our docker image now sets BAZEL_VERSION with the bazel version as installed
@@ -0,0 +1,82 @@
+#!/usr/bin/env python
+# Copyright 2019 The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+"""Upload rows for specific builds to BigQuery, e.g. ones missed while streaming.
+
+Builds go through the same path as streamed ones, so builds that were
+already emitted to a table are not uploaded to it again.
+"""
+
+from __future__ import print_function
+
+import argparse
+import sys
+
+import model
+import make_db
+import stream
+
+
+def parse_build_dir(build_dir):
+    """Split a gs://bucket/.../job/build path into (job, build)."""
+    build_dir = build_dir.strip().rstrip('/')
+    if not build_dir.startswith('gs://') or build_dir.count('/') < 4:
+        raise ValueError('expected gs://bucket/path/job/build, got %r' % build_dir)
+    return tuple(build_dir.rsplit('/', 1))
+
+
+def main(db, tables, build_dirs, batch_size=100, client_class=make_db.GCSClient):
+    """Load and emit the builds in batches, returning how many were emitted."""
+    gcs_client = client_class('', {})
+    todo = [(None, job, build) for job, build in map(parse_build_dir, build_dirs)]
+    found = 0
+    for n in xrange(0, len(todo), batch_size):
+        _, batch = stream.get_started_finished(gcs_client, db, todo[n:n + batch_size])
+        make_db.download_junit(db, 16, client_class)
+        stream.emit_builds(db, tables, batch)
+        found += len(batch)
+        print('BACKFILLED %d/%d' % (min(n + batch_size, len(todo)), len(todo)))
+    return found
+
+
+def get_options(argv):
+    """Process command line arguments."""
+    parser = argparse.ArgumentParser()
+    parser.add_argument(
+        '--dataset',
+        required=True,
+        help='BigQuery dataset (e.g. k8s-gubernator:build)'
+    )
+    parser.add_argument(
+        '--tables',
+        nargs='+',
+        required=True,
+        help='Upload rows to table:days [e.g. --tables day:1 week:7 all:0]',
+    )
+    parser.add_argument(
+        'builds',
+        nargs='*',
+        help='Build dirs to upload (e.g. gs://kubernetes-jenkins/logs/ci-foo/123), '
+        'read from stdin if omitted',
+    )
+    return parser.parse_args(argv)
+
+
+if __name__ == '__main__':
+    OPTIONS = get_options(sys.argv[1:])
+    BUILDS = OPTIONS.builds or [line for line in sys.stdin if line.strip()]
+    main(model.Database(),
+         stream.load_tables(OPTIONS.dataset, OPTIONS.tables),
+         BUILDS)
@@ -33,5 +33,5 @@ while true; do
   # Attempt to update buckets.yaml.
   curl -fsSL --retry 5 -o buckets.yaml.new https://raw.githubusercontent.com/kubernetes/test-infra/master/kettle/buckets.yaml && mv buckets.yaml.new /kettle/buckets.yaml
 
-  /kettle/update.py
+  /kettle/update.py "$@"
 done
@@ -41,13 +41,29 @@ import make_db
 import make_json
 
 
+# prowjob states reported by crier once a job will not run anymore
+FINAL_STATES = ('success', 'failure', 'aborted', 'error')
+
+
 def process_changes(results):
-    """Split GCS change events into trivial acks and builds to further process."""
+    """Split change events into trivial acks and builds to further process.
+
+    Events are either GCS object notifications or prowjob reports published
+    by crier's pubsub reporter.
+    """
     acks = []  # pubsub message ids to acknowledge
     todo = []  # (id, job, build) of builds to grab
 
     # process results, find finished builds to process
     for ack_id, message in results:
+        if 'eventType' not in message.attributes:
+            build_dir = crier_build_dir(message)
+            if not build_dir:
+                acks.append(ack_id)
+                continue
+            job, build = build_dir.rsplit('/', 1)
+            todo.append((ack_id, job, build))
+            continue
         if message.attributes['eventType'] != 'OBJECT_FINALIZE':
             acks.append(ack_id)
             continue
@@ -62,6 +78,22 @@ def process_changes(results):
     return acks, todo
 
 
+def crier_build_dir(message):
+    """Return the build dir of a finished prowjob reported by crier, or None."""
+    try:
+        report = json.loads(message.data)
+    except (TypeError, ValueError):
+        print('ERROR: unable to decode message', repr(message.data))
+        return None
+    if not isinstance(report, dict) or report.get('status') not in FINAL_STATES:
+        return None
+    build_dir = report.get('gcs_path', '').rstrip('/')
+    if not build_dir.startswith('gs://') or build_dir.count('/') < 4:
+        print('ERROR: unexpected gcs_path', report)
+        return None
+    return build_dir
+
+
 def get_started_finished(gcs_client, db, todo):
     """Download started/finished.json from build dirs in todo."""
     acks = []
@@ -194,20 +226,29 @@ def main(db, sub, tables, client_class=make_db.GCSClient, stop=None):
 
         acks, build_dirs = get_started_finished(gcs_client, db, todo)
 
-        # notify pubsub queue that we've handled the finished.json messages
+        # grab junit files for new builds
+        make_db.download_junit(db, 16, client_class)
+
+        # stream new rows to tables
+        emit_builds(db, tables, build_dirs)
+
+        # notify pubsub queue that we've handled the finished builds only
+        # once their rows are recorded as emitted, so a crash before then
+        # redelivers them instead of dropping them. Builds delivered more
+        # than once are deduplicated by the emitted tables.
         if acks:
             print('ACK "finished.json"', len(acks))
             retry(sub.acknowledge, acks)
 
-        # grab junit files for new builds
-        make_db.download_junit(db, 16, client_class)
 
-        # stream new rows to tables
-        if build_dirs and tables:
-            for table, incremental_table in tables.itervalues():
-                builds = db.get_builds_from_paths(build_dirs, incremental_table)
-                emitted = insert_data(table, make_json.make_rows(db, builds))
-                db.insert_emitted(emitted, incremental_table)
+def emit_builds(db, tables, build_dirs):
+    """Upload rows of the builds to every table they were not emitted to yet."""
+    if not build_dirs or not tables:
+        return
+    for table, incremental_table in tables.itervalues():
+        builds = db.get_builds_from_paths(build_dirs, incremental_table)
+        emitted = insert_data(table, make_json.make_rows(db, builds))
+        db.insert_emitted(emitted, incremental_table)
 
 
 def load_sub(poll):
@@ -278,7 +319,7 @@ def get_options(argv):
     parser.add_argument(
         '--poll',
         required=True,
-        help='Follow GCS changes from project/topic/subscription',
+        help='Follow GCS changes or crier prowjob reports from project/topic/subscription',
     )
     parser.add_argument(
         '--dataset',
@@ -16,6 +16,7 @@
 
 # pylint: disable=missing-docstring
 
+import json
 import unittest
 
 import stream
@@ -55,6 +56,12 @@ class Attrs(object):
         self.attributes = attributes
 
 
+class CrierReport(object):
+    def __init__(self, report):
+        self.attributes = {}
+        self.data = json.dumps(report)
+
+
 class FakeSchemaField(object):
     def __init__(self, **kwargs):
         self.__dict__ = kwargs
@@ -109,7 +116,6 @@ class StreamTest(unittest.TestCase):
             [['pull', False], ['pull', True], ['pull', True],
              ['ack', ['a']],
              ['modify-ack', ['b'], 180],
-             ['ack', ['b']],
              ['insert-data',
               ([[5,
                  now - 5,
@@ -131,12 +137,71 @@ class StreamTest(unittest.TestCase):
                  None]],
                [1]),
               {'skip_invalid_rows': True}],
+             ['ack', ['b']],
              ['pull', False], ['pull', True],
              ['modify-ack', ['c'], 180],
              ['ack', ['c']],
              ['pull', False], ['pull', True],
              ['ack', ['d']]])
 
+    def test_crier_reports(self):
+        db = model.Database(':memory:')
+        fakesub = FakeSub([
+            [
+                ('a', CrierReport({
+                    'status': 'pending',
+                    'gcs_path': 'gs://kubernetes-jenkins/logs/fake/123'})),
+                ('b', CrierReport({
+                    'status': 'success',
+                    'gcs_path': 'gs://kubernetes-jenkins/logs/fake/123'})),
+                ('c', CrierReport({'status': 'failure', 'gcs_path': ''})),
+            ],
+            [],
+            [
+                ('d', CrierReport({
+                    'status': 'success',
+                    'gcs_path': 'gs://kubernetes-jenkins/logs/fake/123/'})),
+                ('e', Attrs({
+                    'eventType': 'OBJECT_FINALIZE',
+                    'objectId': 'logs/fake/123/finished.json',
+                    'bucketId': 'kubernetes-jenkins'})),
+            ],
+            [],
+        ])
+        faketable = FakeTable('day', stream.load_schema(FakeSchemaField), fakesub.trace)
+        tables = {'day': (faketable, 'incr')}
+        stream.main(
+            db, fakesub, tables, make_db_test.MockedClient, [1, 0, 0].pop)
+
+        trace = [t[0] for t in fakesub.trace]
+        # the build is only inserted once, even when events for it are
+        # delivered several times or by both sources
+        self.assertEqual(trace.count('insert-data'), 1)
+        self.assertEqual(
+            [t for t in fakesub.trace if t[0] in ('ack', 'modify-ack')],
+            [['ack', ['a', 'c']],
+             ['modify-ack', ['b'], 180],
+             ['ack', ['b']],
+             ['modify-ack', ['d', 'e'], 180],
+             ['ack', ['d', 'e']]])
+        self.assertLess(trace.index('insert-data'), trace.index('ack', 1))
+
+    def test_crier_build_dir(self):
+        for report, expected in [
+                ({'status': 'success', 'gcs_path': 'gs://bucket/logs/job/1'},
+                 'gs://bucket/logs/job/1'),
+                ({'status': 'aborted', 'gcs_path': 'gs://bucket/pr-logs/pull/1/job/2/'},
+                 'gs://bucket/pr-logs/pull/1/job/2'),
+                ({'status': 'triggered', 'gcs_path': 'gs://bucket/logs/job/1'}, None),
+                ({'status': 'error', 'gcs_path': 'https://example.com/job/1'}, None),
+                ({'status': 'error'}, None),
+                ('not a report', None),
+        ]:
+            self.assertEqual(stream.crier_build_dir(CrierReport(report)), expected)
+        bad = CrierReport({})
+        bad.data = 'not json'
+        self.assertIsNone(stream.crier_build_dir(bad))
+
 
 if __name__ == '__main__':
     unittest.main()
@@ -15,7 +15,9 @@
 # limitations under the License.
 
 
+import argparse
 import os
+import sys
 
 
 def call(cmd):
@@ -25,7 +27,12 @@ def call(cmd):
         raise OSError('invocation failed')
 
 
-def main():
+def main(opts):
+    if opts.stream_only:
+        # builds are ingested as their events arrive, skip the bucket scans
+        stream(opts.poll)
+        return
+
     call('time python make_db.py --buckets buckets.yaml --junit --threads 32')
 
     bq_cmd = 'bq load --source_format=NEWLINE_DELIMITED_JSON --max_bad_records=1000'
@@ -49,11 +56,32 @@ def main():
     call(mj_cmd + ' | pv | gzip > build_all.json.gz')
     call(bq_cmd + ' k8s-gubernator:build.all build_all.json.gz schema.json')
 
-    call('python stream.py --poll kubernetes-jenkins/gcs-changes/kettle '
-         ' --dataset k8s-gubernator:build --tables all:0 day:1 week:7 --stop_at=1')
+    stream(opts.poll)
+
+
+def stream(poll):
+    call('python stream.py --poll %s '
+         ' --dataset k8s-gubernator:build --tables all:0 day:1 week:7 --stop_at=1' % poll)
+
+
+def get_options(argv):
+    """Process command line arguments."""
+    parser = argparse.ArgumentParser()
+    parser.add_argument(
+        '--poll',
+        default='kubernetes-jenkins/gcs-changes/kettle',
+        help='Stream builds from project/topic/subscription of GCS changes or crier reports',
+    )
+    parser.add_argument(
+        '--stream-only',
+        action='store_true',
+        help='Only stream builds, without the nightly scans of all buckets',
+    )
+    return parser.parse_args(argv)
 
 
 if __name__ == '__main__':
+    OPTIONS = get_options(sys.argv[1:])
     os.chdir(os.path.dirname(__file__))
     os.environ['TZ'] = 'America/Los_Angeles'
-    main()
+    main(OPTIONS)
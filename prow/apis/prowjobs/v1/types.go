@@ -373,7 +373,10 @@ func (g *GCSConfiguration) Validate() error {
 
 // ProwJobStatus provides runtime metadata, such as when it finished, whether it is running, etc.
 type ProwJobStatus struct {
-	StartTime      metav1.Time  `json:"startTime,omitempty"`
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// PendingTime is when the job started executing, e.g.
+	// when plank created the pod for the job.
+	PendingTime    *metav1.Time `json:"pendingTime,omitempty"`
 	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 	State          ProwJobState `json:"state,omitempty"`
 	Description    string       `json:"description,omitempty"`
@@ -415,6 +418,13 @@ func (j *ProwJob) SetComplete() {
 	*j.Status.CompletionTime = metav1.Now()
 }
 
+// SetPending marks the job as pending (at time now).
+func (j *ProwJob) SetPending() {
+	j.Status.State = PendingState
+	j.Status.PendingTime = new(metav1.Time)
+	*j.Status.PendingTime = metav1.Now()
+}
+
 // ClusterAlias specifies the key in the clusters map to use.
 //
 // This allows scheduling a prow job somewhere aside from the default build cluster.
@@ -0,0 +1,291 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+)
+
+// triggerFields are the fields of a job that determine when it runs
+// and where it reports, which are called out in detail when changed
+var triggerFields = sets.NewString(
+	"always_run",
+	"branches",
+	"context",
+	"cron",
+	"interval",
+	"optional",
+	"rerun_command",
+	"run_if_changed",
+	"skip_branches",
+	"skip_report",
+	"trigger",
+)
+
+// fieldChange records the old and new value of a changed job field
+type fieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+// jobChange describes how one job differs between two configurations
+type jobChange struct {
+	Type prowapi.ProwJobType `json:"type"`
+	Name string              `json:"name"`
+	Repo string              `json:"repo,omitempty"`
+	// Branches are the branches the job runs on before or after the
+	// change, empty if it runs on all branches
+	Branches []string `json:"branches,omitempty"`
+	// TriggerChanges lists changes to when the job runs or where it reports
+	TriggerChanges []fieldChange `json:"trigger_changes,omitempty"`
+	// OtherChanges lists the names of all other fields that changed
+	OtherChanges []string `json:"other_changes,omitempty"`
+}
+
+// configDiff holds the jobs that differ between two configurations
+type configDiff struct {
+	Added         []jobChange `json:"added,omitempty"`
+	Removed       []jobChange `json:"removed,omitempty"`
+	Modified      []jobChange `json:"modified,omitempty"`
+	AffectedRepos []string    `json:"affected_repos,omitempty"`
+}
+
+// comparableJob is a job flattened to its serialized fields
+type comparableJob struct {
+	jobType  prowapi.ProwJobType
+	name     string
+	repo     string
+	brancher config.Brancher
+	fields   map[string]interface{}
+}
+
+func flatten(job interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	return fields, json.Unmarshal(raw, &fields)
+}
+
+// jobsByKey indexes all jobs in the config by a key unique to each job.
+// Jobs are identified by type, repo and name; jobs that share those
+// are further distinguished by the branches they run against.
+func jobsByKey(cfg *config.JobConfig) (map[string]comparableJob, error) {
+	var jobs []comparableJob
+	add := func(jobType prowapi.ProwJobType, repo string, brancher config.Brancher, name string, job interface{}) error {
+		fields, err := flatten(job)
+		if err != nil {
+			return fmt.Errorf("could not serialize %s %s: %v", jobType, name, err)
+		}
+		jobs = append(jobs, comparableJob{jobType: jobType, name: name, repo: repo, brancher: brancher, fields: fields})
+		return nil
+	}
+	for repo, presubmits := range cfg.Presubmits {
+		for _, job := range presubmits {
+			if err := add(prowapi.PresubmitJob, repo, job.Brancher, job.Name, job); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for repo, postsubmits := range cfg.Postsubmits {
+		for _, job := range postsubmits {
+			if err := add(prowapi.PostsubmitJob, repo, job.Brancher, job.Name, job); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, job := range cfg.Periodics {
+		if err := add(prowapi.PeriodicJob, "", config.Brancher{}, job.Name, job); err != nil {
+			return nil, err
+		}
+	}
+
+	baseKey := func(job comparableJob) string {
+		return strings.Join([]string{string(job.jobType), job.repo, job.name}, "/")
+	}
+	counts := map[string]int{}
+	for _, job := range jobs {
+		counts[baseKey(job)]++
+	}
+	byKey := map[string]comparableJob{}
+	for _, job := range jobs {
+		key := baseKey(job)
+		if counts[key] > 1 {
+			key = fmt.Sprintf("%s@%s-%s", key, strings.Join(job.brancher.Branches, ","), strings.Join(job.brancher.SkipBranches, ","))
+		}
+		byKey[key] = job
+	}
+	return byKey, nil
+}
+
+func changeFor(job comparableJob, branches []string) jobChange {
+	return jobChange{Type: job.jobType, Name: job.name, Repo: job.repo, Branches: branches}
+}
+
+// compareJobConfigs determines which jobs were added, removed or modified
+// from the old configuration to the new one
+func compareJobConfigs(old, new *config.JobConfig) (*configDiff, error) {
+	oldJobs, err := jobsByKey(old)
+	if err != nil {
+		return nil, err
+	}
+	newJobs, err := jobsByKey(new)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &configDiff{}
+	repos := sets.NewString()
+	for key, oldJob := range oldJobs {
+		newJob, exists := newJobs[key]
+		if !exists {
+			diff.Removed = append(diff.Removed, changeFor(oldJob, oldJob.brancher.Branches))
+			repos.Insert(oldJob.repo)
+			continue
+		}
+		fields := sets.NewString()
+		for field := range oldJob.fields {
+			fields.Insert(field)
+		}
+		for field := range newJob.fields {
+			fields.Insert(field)
+		}
+		var branches []string
+		if len(oldJob.brancher.Branches) > 0 && len(newJob.brancher.Branches) > 0 {
+			branches = sets.NewString(oldJob.brancher.Branches...).Union(sets.NewString(newJob.brancher.Branches...)).List()
+		}
+		change := changeFor(newJob, branches)
+		for _, field := range fields.List() {
+			oldValue, newValue := oldJob.fields[field], newJob.fields[field]
+			if reflect.DeepEqual(oldValue, newValue) {
+				continue
+			}
+			if triggerFields.Has(field) {
+				change.TriggerChanges = append(change.TriggerChanges, fieldChange{Field: field, Old: oldValue, New: newValue})
+			} else {
+				change.OtherChanges = append(change.OtherChanges, field)
+			}
+		}
+		if len(change.TriggerChanges) > 0 || len(change.OtherChanges) > 0 {
+			diff.Modified = append(diff.Modified, change)
+			repos.Insert(newJob.repo)
+		}
+	}
+	for key, newJob := range newJobs {
+		if _, exists := oldJobs[key]; !exists {
+			diff.Added = append(diff.Added, changeFor(newJob, newJob.brancher.Branches))
+			repos.Insert(newJob.repo)
+		}
+	}
+
+	for _, changes := range [][]jobChange{diff.Added, diff.Removed, diff.Modified} {
+		sort.Slice(changes, func(i, j int) bool {
+			if changes[i].Type != changes[j].Type {
+				return changes[i].Type < changes[j].Type
+			}
+			if changes[i].Repo != changes[j].Repo {
+				return changes[i].Repo < changes[j].Repo
+			}
+			return changes[i].Name < changes[j].Name
+		})
+	}
+	repos.Delete("")
+	diff.AffectedRepos = repos.List()
+	return diff, nil
+}
+
+func (c jobChange) describe() string {
+	description := fmt.Sprintf("%s `%s`", c.Type, c.Name)
+	if c.Repo != "" {
+		description = fmt.Sprintf("%s in `%s`", description, c.Repo)
+	}
+	if c.Type != prowapi.PeriodicJob {
+		if len(c.Branches) == 0 {
+			description += " on all branches"
+		} else {
+			description += fmt.Sprintf(" on branches %s", strings.Join(c.Branches, ", "))
+		}
+	}
+	return description
+}
+
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "unset"
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return "`" + string(raw) + "`"
+}
+
+// writeMarkdown renders the diff as markdown suitable for a PR comment
+func (d *configDiff) writeMarkdown(out io.Writer) error {
+	var b strings.Builder
+	if len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0 {
+		b.WriteString("No job configuration changes.\n")
+		_, err := io.WriteString(out, b.String())
+		return err
+	}
+	b.WriteString("## Job configuration changes\n\n")
+	if len(d.AffectedRepos) > 0 {
+		fmt.Fprintf(&b, "Affected repos: %s\n\n", strings.Join(d.AffectedRepos, ", "))
+	}
+	for _, section := range []struct {
+		title   string
+		changes []jobChange
+	}{
+		{title: "Added jobs", changes: d.Added},
+		{title: "Removed jobs", changes: d.Removed},
+	} {
+		if len(section.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "### %s\n\n", section.title)
+		for _, change := range section.changes {
+			fmt.Fprintf(&b, "* %s\n", change.describe())
+		}
+		b.WriteString("\n")
+	}
+	if len(d.Modified) > 0 {
+		b.WriteString("### Modified jobs\n\n")
+		for _, change := range d.Modified {
+			fmt.Fprintf(&b, "* %s\n", change.describe())
+			for _, field := range change.TriggerChanges {
+				fmt.Fprintf(&b, "  * `%s` changed from %s to %s\n", field.Field, formatValue(field.Old), formatValue(field.New))
+			}
+			if len(change.OtherChanges) > 0 {
+				fmt.Fprintf(&b, "  * also changed: %s\n", strings.Join(change.OtherChanges, ", "))
+			}
+		}
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
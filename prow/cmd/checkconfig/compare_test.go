@@ -0,0 +1,132 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	coreapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+)
+
+func presubmit(name, context string, branches ...string) config.Presubmit {
+	return config.Presubmit{
+		JobBase:  config.JobBase{Name: name, Spec: &coreapi.PodSpec{Containers: []coreapi.Container{{Image: "image"}}}},
+		Reporter: config.Reporter{Context: context},
+		Brancher: config.Brancher{Branches: branches},
+	}
+}
+
+func TestCompareJobConfigs(t *testing.T) {
+	modified := presubmit("modified", "new-context")
+	modified.Spec = &coreapi.PodSpec{Containers: []coreapi.Container{{Image: "other-image"}}}
+
+	old := &config.JobConfig{
+		Presubmits: map[string][]config.Presubmit{
+			"org/repo": {
+				presubmit("unchanged", "unchanged"),
+				presubmit("removed", "removed"),
+				presubmit("modified", "old-context"),
+				presubmit("branched", "branched", "release-1.0"),
+				presubmit("branched", "branched", "release-1.1"),
+			},
+		},
+		Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "periodic"}, Interval: "1h"}},
+	}
+	new := &config.JobConfig{
+		Presubmits: map[string][]config.Presubmit{
+			"org/repo": {
+				presubmit("unchanged", "unchanged"),
+				modified,
+				presubmit("branched", "branched", "release-1.0"),
+				presubmit("branched", "branched", "release-1.2"),
+			},
+			"org/other": {presubmit("added", "added")},
+		},
+		Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "periodic"}, Interval: "2h"}},
+	}
+
+	expected := &configDiff{
+		Added: []jobChange{
+			{Type: prowapi.PresubmitJob, Name: "added", Repo: "org/other"},
+			{Type: prowapi.PresubmitJob, Name: "branched", Repo: "org/repo", Branches: []string{"release-1.2"}},
+		},
+		Removed: []jobChange{
+			{Type: prowapi.PresubmitJob, Name: "branched", Repo: "org/repo", Branches: []string{"release-1.1"}},
+			{Type: prowapi.PresubmitJob, Name: "removed", Repo: "org/repo"},
+		},
+		Modified: []jobChange{
+			{Type: prowapi.PeriodicJob, Name: "periodic", TriggerChanges: []fieldChange{{Field: "interval", Old: "1h", New: "2h"}}},
+			{
+				Type: prowapi.PresubmitJob, Name: "modified", Repo: "org/repo",
+				TriggerChanges: []fieldChange{{Field: "context", Old: "old-context", New: "new-context"}},
+				OtherChanges:   []string{"spec"},
+			},
+		},
+		AffectedRepos: []string{"org/other", "org/repo"},
+	}
+
+	actual, err := compareJobConfigs(old, new)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got incorrect diff: %s", diff.ObjectReflectDiff(expected, actual))
+	}
+
+	var out bytes.Buffer
+	if err := actual.writeMarkdown(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedMarkdown := "## Job configuration changes\n\n" +
+		"Affected repos: org/other, org/repo\n\n" +
+		"### Added jobs\n\n" +
+		"* presubmit `added` in `org/other` on all branches\n" +
+		"* presubmit `branched` in `org/repo` on branches release-1.2\n\n" +
+		"### Removed jobs\n\n" +
+		"* presubmit `branched` in `org/repo` on branches release-1.1\n" +
+		"* presubmit `removed` in `org/repo` on all branches\n\n" +
+		"### Modified jobs\n\n" +
+		"* periodic `periodic`\n" +
+		"  * `interval` changed from `\"1h\"` to `\"2h\"`\n" +
+		"* presubmit `modified` in `org/repo` on all branches\n" +
+		"  * `context` changed from `\"old-context\"` to `\"new-context\"`\n" +
+		"  * also changed: spec\n"
+	if out.String() != expectedMarkdown {
+		t.Errorf("got incorrect markdown: %s", diff.StringDiff(expectedMarkdown, out.String()))
+	}
+}
+
+func TestCompareJobConfigsNoChanges(t *testing.T) {
+	cfg := &config.JobConfig{Presubmits: map[string][]config.Presubmit{"org/repo": {presubmit("job", "job")}}}
+	actual, err := compareJobConfigs(cfg, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out bytes.Buffer
+	if err := actual.writeMarkdown(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "No job configuration changes.\n" {
+		t.Errorf("expected no changes, got %q", out.String())
+	}
+}
@@ -0,0 +1,373 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/errorutil"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+// severity determines how a finding is treated when reported
+type severity string
+
+const (
+	// severityOff disables a rule
+	severityOff severity = "off"
+	// severityWarn reports a finding without failing
+	severityWarn severity = "warn"
+	// severityError reports a finding and fails the check
+	severityError severity = "error"
+)
+
+func (s severity) validate() error {
+	switch s {
+	case severityOff, severityWarn, severityError:
+		return nil
+	}
+	return fmt.Errorf("invalid severity %q, valid severities: %v", s, []severity{severityOff, severityWarn, severityError})
+}
+
+// finding is a single problem detected by a lint rule
+type finding struct {
+	Rule     string   `json:"rule"`
+	Severity severity `json:"severity"`
+	Org      string   `json:"org,omitempty"`
+	Repo     string   `json:"repo,omitempty"`
+	Job      string   `json:"job,omitempty"`
+	// File is the config file the offending job was loaded from, if known
+	File    string `json:"file,omitempty"`
+	Message string `json:"message"`
+}
+
+// lintRule checks the configuration for one class of problems
+type lintRule struct {
+	// name identifies the rule in --warnings and in the lint config
+	name string
+	// needsPlugins is set if the rule can only run when the plugin
+	// configuration is provided
+	needsPlugins bool
+	check        func(cfg *config.Config, pcfg *plugins.Configuration) []finding
+}
+
+// fromError adapts a check that reports an aggregate of global problems
+// into a rule check
+func fromError(check func(cfg *config.Config, pcfg *plugins.Configuration) error) func(cfg *config.Config, pcfg *plugins.Configuration) []finding {
+	return func(cfg *config.Config, pcfg *plugins.Configuration) []finding {
+		err := check(cfg, pcfg)
+		if err == nil {
+			return nil
+		}
+		var messages []string
+		if agg, ok := err.(errorutil.Aggregate); ok {
+			messages = agg.Strings()
+		} else {
+			messages = []string{err.Error()}
+		}
+		var findings []finding
+		for _, message := range messages {
+			findings = append(findings, finding{Message: message})
+		}
+		return findings
+	}
+}
+
+// lintConfig holds the severity of every rule, optionally
+// overridden for specific orgs
+type lintConfig struct {
+	// Rules maps rule names to their severity. Rules that
+	// are not listed default to warn.
+	Rules map[string]severity `json:"rules,omitempty"`
+	// Orgs maps org names to overrides for rule severities
+	// for findings in that org.
+	Orgs map[string]map[string]severity `json:"orgs,omitempty"`
+}
+
+func loadLintConfig(path string) (*lintConfig, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read lint config: %v", err)
+	}
+	var c lintConfig
+	if err := yaml.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("could not unmarshal lint config: %v", err)
+	}
+	return &c, c.validate()
+}
+
+func (c *lintConfig) validate() error {
+	known := sets.NewString(allWarnings...)
+	check := func(rules map[string]severity) error {
+		for rule, s := range rules {
+			if !known.Has(rule) {
+				return fmt.Errorf("no such rule %q, valid rules: %v", rule, allWarnings)
+			}
+			if err := s.validate(); err != nil {
+				return fmt.Errorf("rule %q: %v", rule, err)
+			}
+		}
+		return nil
+	}
+	if err := check(c.Rules); err != nil {
+		return err
+	}
+	for org, rules := range c.Orgs {
+		if err := check(rules); err != nil {
+			return fmt.Errorf("org %q: %v", org, err)
+		}
+	}
+	return nil
+}
+
+// severityFor determines the severity of a rule's findings for an org
+func (c *lintConfig) severityFor(rule, org string) severity {
+	if c == nil {
+		return severityWarn
+	}
+	if s, ok := c.Orgs[org][rule]; ok && org != "" {
+		return s
+	}
+	if s, ok := c.Rules[rule]; ok {
+		return s
+	}
+	return severityWarn
+}
+
+// lint runs the enabled rules and returns their findings with
+// severities resolved, dropping findings for rules that are off
+func lint(rules []lintRule, cfg *config.Config, pcfg *plugins.Configuration, lc *lintConfig) []finding {
+	var findings []finding
+	for _, rule := range rules {
+		if rule.needsPlugins && pcfg == nil {
+			continue
+		}
+		for _, f := range rule.check(cfg, pcfg) {
+			f.Rule = rule.name
+			f.Severity = lc.severityFor(rule.name, f.Org)
+			if f.Severity == severityOff {
+				continue
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func splitRepo(orgRepo string) (string, string) {
+	parts := strings.SplitN(orgRepo, "/", 2)
+	if len(parts) != 2 {
+		return orgRepo, ""
+	}
+	return parts[0], parts[1]
+}
+
+// validateUnusedPresets finds presets that apply to no job
+func validateUnusedPresets(cfg *config.Config, _ *plugins.Configuration) []finding {
+	var jobLabels []map[string]string
+	for _, job := range cfg.AllPresubmits(nil) {
+		jobLabels = append(jobLabels, job.Labels)
+	}
+	for _, job := range cfg.AllPostsubmits(nil) {
+		jobLabels = append(jobLabels, job.Labels)
+	}
+	for _, job := range cfg.AllPeriodics() {
+		jobLabels = append(jobLabels, job.Labels)
+	}
+
+	var findings []finding
+	for _, preset := range cfg.Presets {
+		used := false
+		for _, labels := range jobLabels {
+			matches := true
+			for key, value := range preset.Labels {
+				if actual, ok := labels[key]; !ok || actual != value {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				used = true
+				break
+			}
+		}
+		if !used {
+			var selector []string
+			for key, value := range preset.Labels {
+				selector = append(selector, key+"="+value)
+			}
+			sort.Strings(selector)
+			findings = append(findings, finding{Message: fmt.Sprintf("the preset selecting labels %s is not used by any job", strings.Join(selector, ","))})
+		}
+	}
+	return findings
+}
+
+func isSSHCloneURI(uri string) bool {
+	return strings.HasPrefix(uri, "git@") || strings.HasPrefix(uri, "ssh://")
+}
+
+// clonesOverSSH determines if a decorated job needs SSH keys to clone
+func clonesOverSSH(base config.JobBase) bool {
+	if isSSHCloneURI(base.CloneURI) {
+		return true
+	}
+	for _, ref := range base.ExtraRefs {
+		if isSSHCloneURI(ref.CloneURI) {
+			return true
+		}
+	}
+	return false
+}
+
+func missingSSHKeys(base config.JobBase) bool {
+	if !base.Decorate || !clonesOverSSH(base) {
+		return false
+	}
+	return base.DecorationConfig == nil || len(base.DecorationConfig.SSHKeySecrets) == 0
+}
+
+// validateSSHKeyDecoration finds decorated jobs that clone over
+// SSH but do not configure any SSH keys to clone with
+func validateSSHKeyDecoration(cfg *config.Config, _ *plugins.Configuration) []finding {
+	message := "the job clones over SSH but does not configure ssh_key_secrets in its decoration config"
+	var findings []finding
+	for orgRepo, jobs := range cfg.Presubmits {
+		org, repo := splitRepo(orgRepo)
+		for _, job := range jobs {
+			if missingSSHKeys(job.JobBase) {
+				findings = append(findings, finding{Org: org, Repo: repo, Job: job.Name, File: job.SourcePath, Message: message})
+			}
+		}
+	}
+	for orgRepo, jobs := range cfg.Postsubmits {
+		org, repo := splitRepo(orgRepo)
+		for _, job := range jobs {
+			if missingSSHKeys(job.JobBase) {
+				findings = append(findings, finding{Org: org, Repo: repo, Job: job.Name, File: job.SourcePath, Message: message})
+			}
+		}
+	}
+	for _, job := range cfg.Periodics {
+		if missingSSHKeys(job.JobBase) {
+			findings = append(findings, finding{Job: job.Name, File: job.SourcePath, Message: message})
+		}
+	}
+	return findings
+}
+
+// branchesFor determines which branches to check for a job. Branch
+// filters that are regular expressions cannot be enumerated, so we
+// consider the default branch for jobs that run on all branches.
+func branchesFor(brancher config.Brancher) []string {
+	if len(brancher.Branches) == 0 {
+		return []string{"master"}
+	}
+	return brancher.Branches
+}
+
+// validateBranchProtection finds repos with jobs that produce required
+// contexts on a branch for which branch protection is not enabled, in
+// which case the contexts are not actually required for merge
+func validateBranchProtection(cfg *config.Config, _ *plugins.Configuration) []finding {
+	var findings []finding
+	for orgRepo, jobs := range cfg.Presubmits {
+		org, repo := splitRepo(orgRepo)
+		branches := sets.NewString()
+		for _, job := range jobs {
+			if job.ContextRequired() && !job.TriggersConditionally() {
+				branches.Insert(branchesFor(job.Brancher)...)
+			}
+		}
+		for _, branch := range branches.List() {
+			required, _, _ := config.BranchRequirements(org, repo, branch, cfg.Presubmits)
+			if len(required) == 0 {
+				continue
+			}
+			policy, err := cfg.GetBranchProtection(org, repo, branch)
+			if err != nil {
+				findings = append(findings, finding{Org: org, Repo: repo, Message: fmt.Sprintf("could not determine branch protection for branch %s: %v", branch, err)})
+				continue
+			}
+			if policy == nil || policy.Protect == nil || !*policy.Protect {
+				sort.Strings(required)
+				findings = append(findings, finding{Org: org, Repo: repo, Message: fmt.Sprintf("branch %s is not protected, so the required contexts %v are not enforced", branch, required)})
+			}
+		}
+	}
+	return findings
+}
+
+// validateTideDeadlocks finds tide queries that can never match a PR
+func validateTideDeadlocks(cfg *config.Config, _ *plugins.Configuration) []finding {
+	var findings []finding
+	for i, query := range cfg.Tide.Queries {
+		conflicting := sets.NewString(query.Labels...).Intersection(sets.NewString(query.MissingLabels...))
+		if conflicting.Len() == 0 {
+			continue
+		}
+		message := fmt.Sprintf("the tide query at position %d both requires and forbids the labels %v, so no PR can ever match it", i, conflicting.List())
+		for _, org := range query.Orgs {
+			findings = append(findings, finding{Org: org, Message: message})
+		}
+		for _, orgRepo := range query.Repos {
+			org, repo := splitRepo(orgRepo)
+			findings = append(findings, finding{Org: org, Repo: repo, Message: message})
+		}
+		if len(query.Orgs) == 0 && len(query.Repos) == 0 {
+			findings = append(findings, finding{Message: message})
+		}
+	}
+	return findings
+}
+
+// validateOverlappingRunIfChanged finds presubmits that trigger on
+// run_if_changed and report to the same context as another presubmit
+// that can run on the same branches, as the jobs would overwrite each
+// other's status
+func validateOverlappingRunIfChanged(cfg *config.Config, _ *plugins.Configuration) []finding {
+	var findings []finding
+	for orgRepo, jobs := range cfg.Presubmits {
+		org, repo := splitRepo(orgRepo)
+		for i := range jobs {
+			for j := i + 1; j < len(jobs); j++ {
+				first, second := jobs[i], jobs[j]
+				if first.SkipReport || second.SkipReport || first.Context != second.Context {
+					continue
+				}
+				if first.RunIfChanged == "" && second.RunIfChanged == "" {
+					continue
+				}
+				if !first.Brancher.Intersects(second.Brancher) {
+					continue
+				}
+				findings = append(findings, finding{
+					Org: org, Repo: repo, Job: second.Name, File: second.SourcePath,
+					Message: fmt.Sprintf("jobs %s and %s can run on the same branches and both report to context %q", first.Name, second.Name, first.Context),
+				})
+			}
+		}
+	}
+	return findings
+}
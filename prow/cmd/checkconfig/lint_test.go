@@ -0,0 +1,258 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/errorutil"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestSeverityFor(t *testing.T) {
+	lc := &lintConfig{
+		Rules: map[string]severity{unusedPresetsWarning: severityError},
+		Orgs: map[string]map[string]severity{
+			"strict": {tideDeadlockWarning: severityError},
+			"lax":    {unusedPresetsWarning: severityOff},
+		},
+	}
+	var testCases = []struct {
+		name     string
+		config   *lintConfig
+		rule     string
+		org      string
+		expected severity
+	}{
+		{name: "no config defaults to warn", rule: unusedPresetsWarning, expected: severityWarn},
+		{name: "unconfigured rule defaults to warn", config: lc, rule: overlappingChangesWarning, org: "other", expected: severityWarn},
+		{name: "global rule severity", config: lc, rule: unusedPresetsWarning, org: "other", expected: severityError},
+		{name: "org override", config: lc, rule: tideDeadlockWarning, org: "strict", expected: severityError},
+		{name: "org override disables rule", config: lc, rule: unusedPresetsWarning, org: "lax", expected: severityOff},
+		{name: "org override does not apply to other orgs", config: lc, rule: tideDeadlockWarning, org: "lax", expected: severityWarn},
+	}
+	for _, testCase := range testCases {
+		if actual := testCase.config.severityFor(testCase.rule, testCase.org); actual != testCase.expected {
+			t.Errorf("%s: expected severity %q, got %q", testCase.name, testCase.expected, actual)
+		}
+	}
+}
+
+func TestLintConfigValidate(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		config      lintConfig
+		expectedErr bool
+	}{
+		{
+			name:   "valid config",
+			config: lintConfig{Rules: map[string]severity{unusedPresetsWarning: severityError}, Orgs: map[string]map[string]severity{"org": {tideDeadlockWarning: severityOff}}},
+		},
+		{
+			name:        "unknown rule",
+			config:      lintConfig{Rules: map[string]severity{"unknown": severityError}},
+			expectedErr: true,
+		},
+		{
+			name:        "unknown severity for org",
+			config:      lintConfig{Orgs: map[string]map[string]severity{"org": {tideDeadlockWarning: "fatal"}}},
+			expectedErr: true,
+		},
+	}
+	for _, testCase := range testCases {
+		err := testCase.config.validate()
+		if testCase.expectedErr && err == nil {
+			t.Errorf("%s: expected an error but got none", testCase.name)
+		}
+		if !testCase.expectedErr && err != nil {
+			t.Errorf("%s: expected no error but got one: %v", testCase.name, err)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	rules := []lintRule{
+		{name: "global", check: fromError(func(*config.Config, *plugins.Configuration) error {
+			return errorutil.NewAggregate(errors.New("first"), errors.New("second"))
+		})},
+		{name: "per-org", check: func(*config.Config, *plugins.Configuration) []finding {
+			return []finding{{Org: "loud", Message: "loud"}, {Org: "quiet", Message: "quiet"}}
+		}},
+		{name: "plugins", needsPlugins: true, check: func(*config.Config, *plugins.Configuration) []finding {
+			return []finding{{Message: "plugins"}}
+		}},
+	}
+	lc := &lintConfig{Orgs: map[string]map[string]severity{"loud": {"per-org": severityError}, "quiet": {"per-org": severityOff}}}
+
+	expected := []finding{
+		{Rule: "global", Severity: severityWarn, Message: "first"},
+		{Rule: "global", Severity: severityWarn, Message: "second"},
+		{Rule: "per-org", Severity: severityError, Org: "loud", Message: "loud"},
+	}
+	findings := lint(rules, &config.Config{}, nil, lc)
+	if !reflect.DeepEqual(findings, expected) {
+		t.Errorf("got incorrect findings: %s", diff.ObjectReflectDiff(expected, findings))
+	}
+	if !failed(findings, false) {
+		t.Error("expected findings with an error severity to fail")
+	}
+	if failed(findings[:2], false) {
+		t.Error("expected findings with only warnings not to fail")
+	}
+	if !failed(findings[:2], true) {
+		t.Error("expected findings with warnings to fail when strict")
+	}
+
+	withPlugins := lint(rules, &config.Config{}, &plugins.Configuration{}, lc)
+	if len(withPlugins) != len(expected)+1 {
+		t.Errorf("expected rules needing plugins to run when plugins are configured, got %v", withPlugins)
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	findings := []finding{{Rule: tideDeadlockWarning, Severity: severityError, Org: "org", Message: "bad"}}
+	var out bytes.Buffer
+	if err := report(&out, jsonOutput, findings); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var actual []finding
+	if err := json.Unmarshal(out.Bytes(), &actual); err != nil {
+		t.Fatalf("could not unmarshal output %q: %v", out.String(), err)
+	}
+	if !reflect.DeepEqual(actual, findings) {
+		t.Errorf("got incorrect output: %s", diff.ObjectReflectDiff(findings, actual))
+	}
+
+	out.Reset()
+	if err := report(&out, jsonOutput, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "[]\n" {
+		t.Errorf("expected an empty list for no findings, got %q", out.String())
+	}
+}
+
+func TestValidateUnusedPresets(t *testing.T) {
+	cfg := &config.Config{JobConfig: config.JobConfig{
+		Presets: []config.Preset{
+			{Labels: map[string]string{"preset-used": "true"}},
+			{Labels: map[string]string{"preset-unused": "true", "other": "value"}},
+		},
+		Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "job", Labels: map[string]string{"preset-used": "true"}}}},
+	}}
+	findings := validateUnusedPresets(cfg, nil)
+	expected := []finding{{Message: "the preset selecting labels other=value,preset-unused=true is not used by any job"}}
+	if !reflect.DeepEqual(findings, expected) {
+		t.Errorf("got incorrect findings: %s", diff.ObjectReflectDiff(expected, findings))
+	}
+}
+
+func TestValidateSSHKeyDecoration(t *testing.T) {
+	sshJob := func(name string, secrets ...string) config.Presubmit {
+		return config.Presubmit{JobBase: config.JobBase{
+			Name: name,
+			UtilityConfig: config.UtilityConfig{
+				Decorate:         true,
+				CloneURI:         "git@github.com:org/repo.git",
+				DecorationConfig: &prowapi.DecorationConfig{SSHKeySecrets: secrets},
+			},
+		}}
+	}
+	httpsJob := config.Presubmit{JobBase: config.JobBase{Name: "https", UtilityConfig: config.UtilityConfig{Decorate: true}}}
+	extraRefsJob := config.Presubmit{JobBase: config.JobBase{Name: "extra", UtilityConfig: config.UtilityConfig{
+		Decorate:  true,
+		ExtraRefs: []prowapi.Refs{{Org: "other", Repo: "repo", CloneURI: "ssh://git@github.com/other/repo.git"}},
+	}}}
+	cfg := &config.Config{JobConfig: config.JobConfig{Presubmits: map[string][]config.Presubmit{
+		"org/repo": {sshJob("with-keys", "ssh-secret"), sshJob("without-keys"), httpsJob, extraRefsJob},
+	}}}
+
+	expected := []finding{
+		{Org: "org", Repo: "repo", Job: "without-keys", Message: "the job clones over SSH but does not configure ssh_key_secrets in its decoration config"},
+		{Org: "org", Repo: "repo", Job: "extra", Message: "the job clones over SSH but does not configure ssh_key_secrets in its decoration config"},
+	}
+	if findings := validateSSHKeyDecoration(cfg, nil); !reflect.DeepEqual(findings, expected) {
+		t.Errorf("got incorrect findings: %s", diff.ObjectReflectDiff(expected, findings))
+	}
+}
+
+func TestValidateBranchProtection(t *testing.T) {
+	yes := true
+	jobs := map[string][]config.Presubmit{
+		"protected/repo":   {{JobBase: config.JobBase{Name: "job"}, AlwaysRun: true, Reporter: config.Reporter{Context: "job"}}},
+		"unprotected/repo": {{JobBase: config.JobBase{Name: "job"}, AlwaysRun: true, Reporter: config.Reporter{Context: "job"}}},
+		"optional/repo":    {{JobBase: config.JobBase{Name: "job"}, AlwaysRun: true, Optional: true, Reporter: config.Reporter{Context: "job"}}},
+	}
+	cfg := &config.Config{
+		JobConfig: config.JobConfig{Presubmits: jobs},
+		ProwConfig: config.ProwConfig{BranchProtection: config.BranchProtection{
+			Orgs: map[string]config.Org{"protected": {Policy: config.Policy{Protect: &yes}}},
+		}},
+	}
+	expected := []finding{{Org: "unprotected", Repo: "repo", Message: "branch master is not protected, so the required contexts [job] are not enforced"}}
+	if findings := validateBranchProtection(cfg, nil); !reflect.DeepEqual(findings, expected) {
+		t.Errorf("got incorrect findings: %s", diff.ObjectReflectDiff(expected, findings))
+	}
+}
+
+func TestValidateTideDeadlocks(t *testing.T) {
+	cfg := &config.Config{ProwConfig: config.ProwConfig{Tide: config.Tide{Queries: config.TideQueries{
+		{Orgs: []string{"fine"}, Labels: []string{"lgtm"}, MissingLabels: []string{"hold"}},
+		{Orgs: []string{"org"}, Repos: []string{"other/repo"}, Labels: []string{"lgtm", "hold"}, MissingLabels: []string{"hold"}},
+	}}}}
+	message := "the tide query at position 1 both requires and forbids the labels [hold], so no PR can ever match it"
+	expected := []finding{
+		{Org: "org", Message: message},
+		{Org: "other", Repo: "repo", Message: message},
+	}
+	if findings := validateTideDeadlocks(cfg, nil); !reflect.DeepEqual(findings, expected) {
+		t.Errorf("got incorrect findings: %s", diff.ObjectReflectDiff(expected, findings))
+	}
+}
+
+func TestValidateOverlappingRunIfChanged(t *testing.T) {
+	job := func(name, context, runIfChanged string, branches ...string) config.Presubmit {
+		return config.Presubmit{
+			JobBase:             config.JobBase{Name: name},
+			Reporter:            config.Reporter{Context: context},
+			RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: runIfChanged},
+			Brancher:            config.Brancher{Branches: branches},
+		}
+	}
+	cfg := &config.Config{JobConfig: config.JobConfig{Presubmits: map[string][]config.Presubmit{
+		"org/repo": {
+			job("docs", "verify", `^docs/`),
+			job("code", "verify", `\.go$`),
+			job("release", "release", `^docs/`, "release-1.0"),
+			job("release-master", "release", `\.go$`, "master"),
+			job("always", "always", ""),
+			job("always-too", "always", ""),
+		},
+	}}}
+	expected := []finding{{Org: "org", Repo: "repo", Job: "code", Message: `jobs docs and code can run on the same branches and both report to context "verify"`}}
+	if findings := validateOverlappingRunIfChanged(cfg, nil); !reflect.DeepEqual(findings, expected) {
+		t.Errorf("got incorrect findings: %s", diff.ObjectReflectDiff(expected, findings))
+	}
+}
@@ -18,10 +18,13 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -51,23 +54,29 @@ import (
 )
 
 type options struct {
-	configPath    string
-	jobConfigPath string
-	pluginConfig  string
+	configPath     string
+	jobConfigPath  string
+	pluginConfig   string
+	lintConfigPath string
+	outputFormat   string
+
+	// compare holds the old and new job config
+	// paths to report the differences between
+	compare     bool
+	compareArgs []string
+
+	// resolve is the name of a job to print as
+	// Prow will run it instead of validating
+	resolve string
+
+	// expandTemplates prints the jobs generated
+	// from job templates instead of validating
+	expandTemplates bool
 
 	warnings flagutil.Strings
 	strict   bool
 }
 
-func reportWarning(strict bool, errs errorutil.Aggregate) {
-	for _, item := range errs.Strings() {
-		logrus.Warn(item)
-	}
-	if strict {
-		logrus.Fatal("Strict is set and there were warnings")
-	}
-}
-
 func (o *options) warningEnabled(warning string) bool {
 	for _, registeredWarning := range o.warnings.Strings() {
 		if warning == registeredWarning {
@@ -78,25 +87,59 @@ func (o *options) warningEnabled(warning string) bool {
 }
 
 const (
-	mismatchedTideWarning   = "mismatched-tide"
-	nonDecoratedJobsWarning = "non-decorated-jobs"
-	jobNameLengthWarning    = "long-job-names"
-	needsOkToTestWarning    = "needs-ok-to-test"
-	validateOwnersWarning   = "validate-owners"
-	missingTriggerWarning   = "missing-trigger"
-	validateURLsWarning     = "validate-urls"
+	mismatchedTideWarning     = "mismatched-tide"
+	nonDecoratedJobsWarning   = "non-decorated-jobs"
+	jobNameLengthWarning      = "long-job-names"
+	needsOkToTestWarning      = "needs-ok-to-test"
+	validateOwnersWarning     = "validate-owners"
+	missingTriggerWarning     = "missing-trigger"
+	validateURLsWarning       = "validate-urls"
+	unusedPresetsWarning      = "unused-presets"
+	sshKeyDecorationWarning   = "missing-ssh-key-decoration"
+	branchProtectionWarning   = "missing-branch-protection"
+	tideDeadlockWarning       = "tide-deadlock"
+	overlappingChangesWarning = "overlapping-run-if-changed"
 )
 
-var allWarnings = []string{
-	mismatchedTideWarning,
-	nonDecoratedJobsWarning,
-	jobNameLengthWarning,
-	needsOkToTestWarning,
-	validateOwnersWarning,
-	missingTriggerWarning,
-	validateURLsWarning,
+const (
+	textOutput = "text"
+	jsonOutput = "json"
+)
+
+// allRules holds every check checkconfig knows how to run
+var allRules = []lintRule{
+	{name: mismatchedTideWarning, needsPlugins: true, check: fromError(validateTideRequirements)},
+	{name: nonDecoratedJobsWarning, check: fromError(func(cfg *config.Config, _ *plugins.Configuration) error {
+		return validateDecoratedJobs(cfg)
+	})},
+	{name: jobNameLengthWarning, check: fromError(func(cfg *config.Config, _ *plugins.Configuration) error {
+		return validateJobRequirements(cfg.JobConfig)
+	})},
+	{name: needsOkToTestWarning, check: fromError(func(cfg *config.Config, _ *plugins.Configuration) error {
+		return validateNeedsOkToTestLabel(cfg)
+	})},
+	{name: validateOwnersWarning, needsPlugins: true, check: fromError(func(_ *config.Config, pcfg *plugins.Configuration) error {
+		return verifyOwnersPlugin(pcfg)
+	})},
+	{name: missingTriggerWarning, needsPlugins: true, check: fromError(validateTriggers)},
+	{name: validateURLsWarning, needsPlugins: true, check: fromError(func(cfg *config.Config, _ *plugins.Configuration) error {
+		return validateURLs(cfg.ProwConfig)
+	})},
+	{name: unusedPresetsWarning, check: validateUnusedPresets},
+	{name: sshKeyDecorationWarning, check: validateSSHKeyDecoration},
+	{name: branchProtectionWarning, check: validateBranchProtection},
+	{name: tideDeadlockWarning, check: validateTideDeadlocks},
+	{name: overlappingChangesWarning, check: validateOverlappingRunIfChanged},
 }
 
+var allWarnings = func() []string {
+	var names []string
+	for _, rule := range allRules {
+		names = append(names, rule.name)
+	}
+	return names
+}()
+
 func (o *options) Validate() error {
 	if o.configPath == "" {
 		return errors.New("required flag --config-path was unset")
@@ -113,6 +156,21 @@ func (o *options) Validate() error {
 			return fmt.Errorf("no such warning %q, valid warnings: %v", warning, allWarnings)
 		}
 	}
+	modes := 0
+	for _, set := range []bool{o.compare, o.resolve != "", o.expandTemplates} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return errors.New("--compare, --resolve and --expand-templates are mutually exclusive")
+	}
+	if o.compare && len(o.compareArgs) != 2 {
+		return fmt.Errorf("--compare requires exactly two arguments, the old and new job config paths, got %d", len(o.compareArgs))
+	}
+	if o.outputFormat != textOutput && o.outputFormat != jsonOutput {
+		return fmt.Errorf("invalid --output-format %q, valid formats: %v", o.outputFormat, []string{textOutput, jsonOutput})
+	}
 	return nil
 }
 
@@ -121,9 +179,15 @@ func gatherOptions() options {
 	flag.StringVar(&o.configPath, "config-path", "", "Path to config.yaml.")
 	flag.StringVar(&o.jobConfigPath, "job-config-path", "", "Path to prow job configs.")
 	flag.StringVar(&o.pluginConfig, "plugin-config", "", "Path to plugin config file.")
+	flag.StringVar(&o.lintConfigPath, "lint-config", "", "Path to a file configuring the severity of each warning, optionally per org.")
+	flag.StringVar(&o.outputFormat, "output-format", textOutput, "Format to report warnings in, one of text or json.")
 	flag.Var(&o.warnings, "warnings", "Comma-delimited list of warnings to validate.")
 	flag.BoolVar(&o.strict, "strict", false, "If set, consider all warnings as errors.")
+	flag.BoolVar(&o.compare, "compare", false, "If set, report the job changes between the old and new job config paths given as arguments instead of validating.")
+	flag.StringVar(&o.resolve, "resolve", "", "If set, print the named job with all job defaults, presets and decoration config applied instead of validating.")
+	flag.BoolVar(&o.expandTemplates, "expand-templates", false, "If set, print all jobs generated from job templates with the matrix values they were generated for instead of validating.")
 	flag.Parse()
+	o.compareArgs = flag.Args()
 	return o
 }
 
@@ -142,12 +206,41 @@ func main() {
 		logrusutil.NewDefaultFieldsFormatter(&logrus.TextFormatter{}, logrus.Fields{"component": "checkconfig"}),
 	)
 
+	if o.compare {
+		if err := compare(os.Stdout, o.outputFormat, o.configPath, o.compareArgs[0], o.compareArgs[1]); err != nil {
+			logrus.WithError(err).Fatal("Error comparing job configs.")
+		}
+		return
+	}
+
+	var lc *lintConfig
+	if o.lintConfigPath != "" {
+		var err error
+		if lc, err = loadLintConfig(o.lintConfigPath); err != nil {
+			logrus.WithError(err).Fatal("Error loading lint config.")
+		}
+	}
+
 	configAgent := config.Agent{}
 	if err := configAgent.Start(o.configPath, o.jobConfigPath); err != nil {
 		logrus.WithError(err).Fatal("Error loading Prow config.")
 	}
 	cfg := configAgent.Config()
 
+	if o.resolve != "" {
+		if err := resolve(os.Stdout, o.outputFormat, cfg, o.resolve); err != nil {
+			logrus.WithError(err).Fatal("Error resolving job.")
+		}
+		return
+	}
+
+	if o.expandTemplates {
+		if err := expandTemplates(os.Stdout, o.outputFormat, cfg); err != nil {
+			logrus.WithError(err).Fatal("Error printing generated jobs.")
+		}
+		return
+	}
+
 	pluginAgent := plugins.ConfigAgent{}
 	var pcfg *plugins.Configuration
 	if o.pluginConfig != "" {
@@ -161,45 +254,83 @@ func main() {
 	// presence won't lead to strictly incorrect behavior, so we can
 	// detect them here but don't necessarily want to stop config re-load
 	// in all components on their failure.
-	var errs []error
-	if pcfg != nil && o.warningEnabled(mismatchedTideWarning) {
-		if err := validateTideRequirements(cfg, pcfg); err != nil {
-			errs = append(errs, err)
+	var rules []lintRule
+	for _, rule := range allRules {
+		if o.warningEnabled(rule.name) {
+			rules = append(rules, rule)
 		}
 	}
-	if o.warningEnabled(nonDecoratedJobsWarning) {
-		if err := validateDecoratedJobs(cfg); err != nil {
-			errs = append(errs, err)
-		}
+	findings := lint(rules, cfg, pcfg, lc)
+	if err := report(os.Stdout, o.outputFormat, findings); err != nil {
+		logrus.WithError(err).Fatal("Error reporting warnings.")
 	}
-	if o.warningEnabled(jobNameLengthWarning) {
-		if err := validateJobRequirements(cfg.JobConfig); err != nil {
-			errs = append(errs, err)
-		}
+	if failed(findings, o.strict) {
+		logrus.Fatal("There were warnings configured as errors or strict is set and there were warnings")
 	}
-	if o.warningEnabled(needsOkToTestWarning) {
-		if err := validateNeedsOkToTestLabel(cfg); err != nil {
-			errs = append(errs, err)
-		}
+}
+
+// compare loads the old and new job configs and reports their differences
+func compare(out io.Writer, format, configPath, oldJobConfigPath, newJobConfigPath string) error {
+	oldConfig, err := config.Load(configPath, oldJobConfigPath)
+	if err != nil {
+		return fmt.Errorf("could not load old config: %v", err)
 	}
-	if pcfg != nil && o.warningEnabled(validateOwnersWarning) {
-		if err := verifyOwnersPlugin(pcfg); err != nil {
-			errs = append(errs, err)
-		}
+	newConfig, err := config.Load(configPath, newJobConfigPath)
+	if err != nil {
+		return fmt.Errorf("could not load new config: %v", err)
+	}
+	diff, err := compareJobConfigs(&oldConfig.JobConfig, &newConfig.JobConfig)
+	if err != nil {
+		return err
 	}
-	if pcfg != nil && o.warningEnabled(missingTriggerWarning) {
-		if err := validateTriggers(cfg, pcfg); err != nil {
-			errs = append(errs, err)
+	if format == jsonOutput {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("could not marshal job changes: %v", err)
 		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
 	}
-	if pcfg != nil && o.warningEnabled(validateURLsWarning) {
-		if err := validateURLs(cfg.ProwConfig); err != nil {
-			errs = append(errs, err)
+	return diff.writeMarkdown(out)
+}
+
+// report writes the findings in the requested format
+func report(out io.Writer, format string, findings []finding) error {
+	if format == jsonOutput {
+		if findings == nil {
+			findings = []finding{}
+		}
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("could not marshal findings: %v", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+	for _, f := range findings {
+		entry := logrus.WithField("rule", f.Rule)
+		for key, value := range map[string]string{"org": f.Org, "repo": f.Repo, "job": f.Job, "file": f.File} {
+			if value != "" {
+				entry = entry.WithField(key, value)
+			}
+		}
+		if f.Severity == severityError {
+			entry.Error(f.Message)
+		} else {
+			entry.Warn(f.Message)
 		}
 	}
-	if len(errs) > 0 {
-		reportWarning(o.strict, errorutil.NewAggregate(errs...))
+	return nil
+}
+
+// failed determines if the findings should fail the check
+func failed(findings []finding, strict bool) bool {
+	for _, f := range findings {
+		if f.Severity == severityError || strict {
+			return true
+		}
 	}
+	return false
 }
 
 func validateURLs(c config.ProwConfig) error {
@@ -505,6 +636,7 @@ func enabledOrgReposForPlugin(c *plugins.Configuration, plugin string, external
 // Specifically:
 //   - every item in the tide subset must also be in the plugins subset
 //   - every item in the plugins subset that is in the tide superset must also be in the tide subset
+//
 // For example:
 //   - if org/repo is configured in tide to require lgtm, it must have the lgtm plugin enabled
 //   - if org/repo is configured in tide, the tide configuration must require the same set of
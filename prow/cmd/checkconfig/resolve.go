@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+)
+
+// resolvedJob is a job as Prow will run it, after all
+// defaults, presets and decoration config were applied
+type resolvedJob struct {
+	Type       prowapi.ProwJobType `json:"type"`
+	Repo       string              `json:"repo,omitempty"`
+	SourcePath string              `json:"source_path,omitempty"`
+	// MatrixValues are set if the job was generated from a job template
+	MatrixValues map[string]string `json:"matrix_values,omitempty"`
+	Job          interface{}       `json:"job"`
+}
+
+// resolveJobs finds all jobs with the given name in the loaded config
+func resolveJobs(cfg *config.Config, name string) []resolvedJob {
+	var jobs []resolvedJob
+	for repo, presubmits := range cfg.Presubmits {
+		for _, job := range presubmits {
+			if job.Name == name {
+				jobs = append(jobs, resolvedJob{Type: prowapi.PresubmitJob, Repo: repo, SourcePath: job.SourcePath, MatrixValues: job.MatrixValues, Job: job})
+			}
+		}
+	}
+	for repo, postsubmits := range cfg.Postsubmits {
+		for _, job := range postsubmits {
+			if job.Name == name {
+				jobs = append(jobs, resolvedJob{Type: prowapi.PostsubmitJob, Repo: repo, SourcePath: job.SourcePath, MatrixValues: job.MatrixValues, Job: job})
+			}
+		}
+	}
+	for _, job := range cfg.Periodics {
+		if job.Name == name {
+			jobs = append(jobs, resolvedJob{Type: prowapi.PeriodicJob, SourcePath: job.SourcePath, MatrixValues: job.MatrixValues, Job: job})
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].Type != jobs[j].Type {
+			return jobs[i].Type < jobs[j].Type
+		}
+		return jobs[i].Repo < jobs[j].Repo
+	})
+	return jobs
+}
+
+// generatedJobs finds all jobs that were generated from job templates,
+// in a stable order
+func generatedJobs(cfg *config.Config) []resolvedJob {
+	var jobs []resolvedJob
+	for repo, presubmits := range cfg.Presubmits {
+		for _, job := range presubmits {
+			if job.MatrixValues != nil {
+				jobs = append(jobs, resolvedJob{Type: prowapi.PresubmitJob, Repo: repo, SourcePath: job.SourcePath, MatrixValues: job.MatrixValues, Job: job})
+			}
+		}
+	}
+	for repo, postsubmits := range cfg.Postsubmits {
+		for _, job := range postsubmits {
+			if job.MatrixValues != nil {
+				jobs = append(jobs, resolvedJob{Type: prowapi.PostsubmitJob, Repo: repo, SourcePath: job.SourcePath, MatrixValues: job.MatrixValues, Job: job})
+			}
+		}
+	}
+	for _, job := range cfg.Periodics {
+		if job.MatrixValues != nil {
+			jobs = append(jobs, resolvedJob{Type: prowapi.PeriodicJob, SourcePath: job.SourcePath, MatrixValues: job.MatrixValues, Job: job})
+		}
+	}
+	// jobs of one repo keep the order they were generated in
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].SourcePath != jobs[j].SourcePath {
+			return jobs[i].SourcePath < jobs[j].SourcePath
+		}
+		if jobs[i].Type != jobs[j].Type {
+			return jobs[i].Type < jobs[j].Type
+		}
+		return jobs[i].Repo < jobs[j].Repo
+	})
+	return jobs
+}
+
+// resolve prints the fully resolved configuration of the named job
+func resolve(out io.Writer, format string, cfg *config.Config, name string) error {
+	jobs := resolveJobs(cfg, name)
+	if len(jobs) == 0 {
+		return fmt.Errorf("no job named %q is configured", name)
+	}
+	return printJobs(out, format, jobs)
+}
+
+// expandTemplates prints all jobs generated from job templates
+func expandTemplates(out io.Writer, format string, cfg *config.Config) error {
+	return printJobs(out, format, generatedJobs(cfg))
+}
+
+func printJobs(out io.Writer, format string, jobs []resolvedJob) error {
+	var data []byte
+	var err error
+	if format == jsonOutput {
+		data, err = json.MarshalIndent(jobs, "", "  ")
+	} else {
+		data, err = yaml.Marshal(jobs)
+	}
+	if err != nil {
+		return fmt.Errorf("could not marshal jobs: %v", err)
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/config"
+)
+
+func TestResolveJobs(t *testing.T) {
+	cfg := &config.Config{JobConfig: config.JobConfig{
+		Presubmits: map[string][]config.Presubmit{
+			"org/b": {presubmit("job", "job")},
+			"org/a": {presubmit("job", "job"), presubmit("other", "other")},
+		},
+		Periodics: []config.Periodic{{JobBase: config.JobBase{Name: "job"}}},
+	}}
+
+	jobs := resolveJobs(cfg, "job")
+	var actual []string
+	for _, job := range jobs {
+		actual = append(actual, string(job.Type)+":"+job.Repo)
+	}
+	expected := []string{string(prowapi.PeriodicJob) + ":", string(prowapi.PresubmitJob) + ":org/a", string(prowapi.PresubmitJob) + ":org/b"}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected jobs %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected jobs %v, got %v", expected, actual)
+			break
+		}
+	}
+
+	var out bytes.Buffer
+	if err := resolve(&out, textOutput, cfg, "other"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("name: other")) {
+		t.Errorf("expected resolved job in output, got %q", out.String())
+	}
+	if err := resolve(&out, textOutput, cfg, "missing"); err == nil {
+		t.Error("expected an error resolving a job that does not exist")
+	}
+}
+
+func TestGeneratedJobs(t *testing.T) {
+	cfg := &config.Config{JobConfig: config.JobConfig{
+		Presubmits: map[string][]config.Presubmit{
+			"org/repo": {
+				presubmit("handwritten", "handwritten"),
+				{JobBase: config.JobBase{Name: "pull-1.13", SourcePath: "b.yaml", MatrixValues: map[string]string{"version": "1.13"}}},
+			},
+		},
+		Periodics: []config.Periodic{
+			{JobBase: config.JobBase{Name: "ci-1.13", SourcePath: "a.yaml", MatrixValues: map[string]string{"version": "1.13"}}},
+			{JobBase: config.JobBase{Name: "ci-1.14", SourcePath: "a.yaml", MatrixValues: map[string]string{"version": "1.14"}}},
+		},
+	}}
+
+	var actual []string
+	for _, job := range generatedJobs(cfg) {
+		actual = append(actual, job.SourcePath+":"+job.MatrixValues["version"])
+	}
+	expected := []string{"a.yaml:1.13", "a.yaml:1.14", "b.yaml:1.13"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected generated jobs %v, got %v", expected, actual)
+	}
+
+	var out bytes.Buffer
+	if err := expandTemplates(&out, textOutput, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bytes.Contains(out.Bytes(), []byte("handwritten")) {
+		t.Errorf("expected only generated jobs in output, got %q", out.String())
+	}
+}
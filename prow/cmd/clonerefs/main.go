@@ -20,6 +20,7 @@ import (
 	"k8s.io/test-infra/prow/clonerefs"
 	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/pod-utils/options"
+	"k8s.io/test-infra/prow/tracing"
 
 	"github.com/sirupsen/logrus"
 )
@@ -38,7 +39,10 @@ func main() {
 		logrusutil.NewDefaultFieldsFormatter(nil, logrus.Fields{"component": "clonerefs"}),
 	)
 
-	if err := o.Run(); err != nil {
+	finish := tracing.StartPodUtility("clonerefs")
+	err := o.Run()
+	finish(err)
+	if err != nil {
 		logrus.WithError(err).Fatal("Failed to clone refs")
 	}
 
@@ -19,12 +19,14 @@ package main
 import (
 	"errors"
 	"flag"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 
 	v1 "k8s.io/test-infra/prow/apis/prowjobs/v1"
@@ -39,6 +41,7 @@ import (
 	"k8s.io/test-infra/prow/kube"
 	"k8s.io/test-infra/prow/logrusutil"
 	pubsubreporter "k8s.io/test-infra/prow/pubsub/reporter"
+	"k8s.io/test-infra/prow/tracing"
 )
 
 const (
@@ -147,6 +150,7 @@ func main() {
 	if err := configAgent.Start(o.configPath, o.jobConfigPath); err != nil {
 		logrus.WithError(err).Fatal("Error starting config agent.")
 	}
+	tracing.Start("crier", func() tracing.Config { return configAgent.Config().Tracing })
 	cfg := configAgent.Config
 
 	prowjobClientset, err := o.client.ProwJobClientset(cfg().ProwJobNamespace, o.dryrun)
@@ -156,6 +160,9 @@ func main() {
 
 	prowjobInformerFactory := prowjobinformer.NewSharedInformerFactory(prowjobClientset, resync)
 
+	crier.RecordMetrics(prowjobInformerFactory.Prow().V1().ProwJobs())
+	go serve(configAgent)
+
 	var controllers []*crier.Controller
 
 	// track all worker status before shutdown
@@ -253,3 +260,13 @@ func main() {
 		logrus.Info("timed out waiting for all worker to finish")
 	}
 }
+
+// serve starts a http server and serves prometheus metrics,
+// the version of the loaded config and the log level.
+// Meant to be called inside a goroutine.
+func serve(configAgent *config.Agent) {
+	http.Handle("/metrics", promhttp.Handler())
+	http.Handle("/config-version", configAgent.VersionHandler())
+	http.Handle("/log-level", logrusutil.LevelHandler())
+	logrus.WithError(http.ListenAndServe(":8080", nil)).Fatal("ListenAndServe returned.")
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/config"
+)
+
+// configVersionChecker compares the version of the config loaded by deck to
+// the versions loaded by other components. Components reload config at
+// different times, so a component is only reported once it disagrees on
+// two consecutive checks.
+type configVersionChecker struct {
+	sync.Mutex
+	client  *http.Client
+	version func() string
+	// differing holds the components that disagreed on the last check
+	differing map[string]bool
+	// skewed holds the components that disagreed on the last two checks
+	skewed []string
+}
+
+// configSkew is shown as a banner on all pages while components disagree
+var configSkew = &configVersionChecker{}
+
+func newConfigVersionChecker(version func() string) *configVersionChecker {
+	return &configVersionChecker{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		version: version,
+	}
+}
+
+func (c *configVersionChecker) fetch(url string) (string, error) {
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("got status code %d", resp.StatusCode)
+	}
+	var info config.VersionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", fmt.Errorf("could not decode config version: %v", err)
+	}
+	return info.Version, nil
+}
+
+// check fetches the config version of every component and updates
+// which components are skewed
+func (c *configVersionChecker) check(urls map[string]string) {
+	ours := c.version()
+	differing := map[string]bool{}
+	for component, url := range urls {
+		theirs, err := c.fetch(url)
+		if err != nil {
+			logrus.WithError(err).WithField("component", component).Warn("Failed to fetch config version.")
+			continue
+		}
+		if theirs != ours {
+			differing[component] = true
+		}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	var skewed []string
+	for component := range differing {
+		if c.differing[component] {
+			skewed = append(skewed, component)
+		}
+	}
+	sort.Strings(skewed)
+	c.differing = differing
+	c.skewed = skewed
+}
+
+// run checks the config versions periodically, forever
+func (c *configVersionChecker) run(cfg config.Getter, interval time.Duration) {
+	for {
+		if urls := cfg().Deck.ConfigVersionURLs; len(urls) > 0 {
+			c.check(urls)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// warning describes the skew between components, if there is any
+func (c *configVersionChecker) warning() string {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.skewed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("The configuration loaded by %s differs from the one loaded by deck. A config update may not have rolled out everywhere.", strings.Join(c.skewed, ", "))
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConfigVersionChecker(t *testing.T) {
+	versions := map[string]string{"hook": "current", "plank": "current"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		component := r.URL.Path[1:]
+		if component == "broken" {
+			http.Error(w, "broken", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"version": %q, "generation": 1}`, versions[component])
+	}))
+	defer server.Close()
+	urls := map[string]string{"hook": server.URL + "/hook", "plank": server.URL + "/plank", "broken": server.URL + "/broken"}
+
+	checker := newConfigVersionChecker(func() string { return "current" })
+	checker.check(urls)
+	if warning := checker.warning(); warning != "" {
+		t.Errorf("expected no warning when versions agree, got %q", warning)
+	}
+
+	versions["plank"] = "old"
+	checker.check(urls)
+	if warning := checker.warning(); warning != "" {
+		t.Errorf("expected no warning for a single disagreement, got %q", warning)
+	}
+	checker.check(urls)
+	expected := "The configuration loaded by plank differs from the one loaded by deck. A config update may not have rolled out everywhere."
+	if warning := checker.warning(); warning != expected {
+		t.Errorf("expected warning %q, got %q", expected, warning)
+	}
+
+	versions["plank"] = "current"
+	checker.check(urls)
+	if warning := checker.warning(); warning != "" {
+		t.Errorf("expected warning to clear once versions agree, got %q", warning)
+	}
+}
@@ -161,6 +161,9 @@ func main() {
 	// setup common handlers for local and deployed runs
 	mux.Handle("/static/", http.StripPrefix("/static", staticHandlerFromDir(o.staticFilesLocation)))
 	mux.Handle("/config", gziphandler.GzipHandler(handleConfig(cfg)))
+	mux.Handle("/config-version", configAgent.VersionHandler())
+	configSkew = newConfigVersionChecker(func() string { return configAgent.VersionInfo().Version })
+	go configSkew.run(cfg, time.Minute)
 	mux.Handle("/favicon.ico", gziphandler.GzipHandler(handleFavicon(o.staticFilesLocation, cfg)))
 
 	// Set up handlers for template pages.
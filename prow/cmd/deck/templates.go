@@ -67,6 +67,7 @@ func prepareBaseTemplate(o options, cfg config.Getter, t *template.Template) (*t
 		"mobileFriendly":   func() bool { return true },
 		"mobileUnfriendly": func() bool { return false },
 		"deckVersion":      func() string { return version.Version },
+		"configSkew":       configSkew.warning,
 	}).ParseFiles(path.Join(o.templateFilesLocation, "base.html"))
 }
 
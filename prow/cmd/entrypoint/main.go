@@ -17,12 +17,14 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/test-infra/prow/entrypoint"
 	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/pod-utils/options"
+	"k8s.io/test-infra/prow/tracing"
 )
 
 func main() {
@@ -39,5 +41,12 @@ func main() {
 		logrusutil.NewDefaultFieldsFormatter(nil, logrus.Fields{"component": "entrypoint"}),
 	)
 
-	os.Exit(o.Run())
+	finish := tracing.StartPodUtility("entrypoint")
+	code := o.Run()
+	var err error
+	if code != 0 {
+		err = fmt.Errorf("test process exited with code %d", code)
+	}
+	finish(err)
+	os.Exit(code)
 }
@@ -40,10 +40,12 @@ import (
 	"k8s.io/test-infra/prow/plugins"
 	"k8s.io/test-infra/prow/repoowners"
 	"k8s.io/test-infra/prow/slack"
+	"k8s.io/test-infra/prow/tracing"
 )
 
 type options struct {
-	port int
+	port      int
+	adminPort int
 
 	configPath    string
 	jobConfigPath string
@@ -72,6 +74,7 @@ func gatherOptions() options {
 	o := options{}
 	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	fs.IntVar(&o.port, "port", 8888, "Port to listen on.")
+	fs.IntVar(&o.adminPort, "admin-port", 8081, "Port to serve administrative endpoints like /log-level on, not to be exposed publicly.")
 
 	fs.StringVar(&o.configPath, "config-path", "/etc/config/config.yaml", "Path to config.yaml.")
 	fs.StringVar(&o.jobConfigPath, "job-config-path", "", "Path to prow job configs.")
@@ -100,6 +103,7 @@ func main() {
 	if err := configAgent.Start(o.configPath, o.jobConfigPath); err != nil {
 		logrus.WithError(err).Fatal("Error starting config agent.")
 	}
+	tracing.Start("hook", func() tracing.Config { return configAgent.Config().Tracing })
 
 	var tokens []string
 
@@ -192,6 +196,7 @@ func main() {
 	// Return 200 on / for health checks.
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
 	http.Handle("/metrics", promhttp.Handler())
+	http.Handle("/config-version", configAgent.VersionHandler())
 	// For /hook, handle a webhook normally.
 	http.Handle("/hook", server)
 	// Serve plugin help information from /plugin-help.
@@ -199,6 +204,14 @@ func main() {
 
 	httpServer := &http.Server{Addr: ":" + strconv.Itoa(o.port)}
 
+	// Serve administrative endpoints on a separate port so they are not
+	// exposed with the webhook endpoint.
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/log-level", logrusutil.LevelHandler())
+	go func() {
+		logrus.WithError(http.ListenAndServe(":"+strconv.Itoa(o.adminPort), adminMux)).Fatal("Admin server exited.")
+	}()
+
 	// Shutdown gracefully on SIGTERM or SIGINT
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
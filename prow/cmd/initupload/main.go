@@ -27,6 +27,7 @@ import (
 	"k8s.io/test-infra/prow/initupload"
 	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/pod-utils/options"
+	"k8s.io/test-infra/prow/tracing"
 )
 
 func main() {
@@ -43,7 +44,10 @@ func main() {
 		logrusutil.NewDefaultFieldsFormatter(nil, logrus.Fields{"component": "initupload"}),
 	)
 
-	if err := o.Run(); err != nil {
+	finish := tracing.StartPodUtility("initupload")
+	err := o.Run()
+	finish(err)
+	if err != nil {
 		logrus.WithError(err).Fatal("Failed to initialize job")
 	}
 }
@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Phaino emulates a decorated ProwJob pod on the local machine: refs are
+// cloned with clonerefs semantics, the test command runs under the same
+// timeout and grace period the entrypoint enforces and the logs, metadata
+// and artifacts are written to a local directory with the layout the pod
+// utilities would use in GCS.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/logrusutil"
+)
+
+type options struct {
+	prowJobPath string
+	buildID     string
+	workDir     string
+	outputDir   string
+}
+
+func (o *options) Validate() error {
+	if o.prowJobPath == "" {
+		return errors.New("required flag --prow-job was unset")
+	}
+	if o.outputDir == "" {
+		return errors.New("required flag --output-dir was unset")
+	}
+
+	return nil
+}
+
+func gatherOptions() options {
+	o := options{}
+	flag.StringVar(&o.prowJobPath, "prow-job", "", "ProwJob to run, - for stdin.")
+	flag.StringVar(&o.buildID, "build-id", "", "Build ID for the job run, defaults to the ProwJob status or the current time.")
+	flag.StringVar(&o.workDir, "work-dir", "", "Directory to clone code and write logs into, defaults to a temporary directory.")
+	flag.StringVar(&o.outputDir, "output-dir", "", "Directory to write logs and artifacts to, laid out as they would be in GCS.")
+	flag.Parse()
+	return o
+}
+
+func main() {
+	logrus.SetFormatter(logrusutil.NewDefaultFieldsFormatter(&logrus.TextFormatter{}, logrus.Fields{"component": "phaino"}))
+
+	o := gatherOptions()
+	if err := o.Validate(); err != nil {
+		logrus.Fatalf("Invalid options: %v", err)
+	}
+
+	job, err := readProwJob(o.prowJobPath)
+	if err != nil {
+		logrus.WithError(err).Fatal("Could not load ProwJob.")
+	}
+
+	if o.buildID == "" {
+		o.buildID = job.Status.BuildID
+	}
+	if o.buildID == "" {
+		o.buildID = strconv.FormatInt(time.Now().Unix(), 10)
+		logrus.Infof("No BuildID found in ProwJob status or given with --build-id, using %s.", o.buildID)
+	}
+
+	if o.workDir == "" {
+		dir, err := ioutil.TempDir("", "phaino")
+		if err != nil {
+			logrus.WithError(err).Fatal("Could not create working directory.")
+		}
+		o.workDir = dir
+	}
+
+	result, err := run(job, o.buildID, o.workDir, o.outputDir)
+	if err != nil {
+		logrus.WithError(err).Fatal("Could not run ProwJob.")
+	}
+	logrus.WithFields(logrus.Fields{"result": result.result, "path": result.path}).Info("Finished running ProwJob.")
+	if result.result != "SUCCESS" {
+		os.Exit(1)
+	}
+}
+
+func readProwJob(path string) (prowapi.ProwJob, error) {
+	var raw []byte
+	var err error
+	if path == "-" {
+		raw, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		raw, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return prowapi.ProwJob{}, fmt.Errorf("could not read ProwJob YAML: %v", err)
+	}
+
+	var job prowapi.ProwJob
+	if err := yaml.Unmarshal(raw, &job); err != nil {
+		return prowapi.ProwJob{}, fmt.Errorf("could not unmarshal ProwJob YAML: %v", err)
+	}
+	return job, nil
+}
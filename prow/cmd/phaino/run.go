@@ -0,0 +1,340 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/clonerefs"
+	"k8s.io/test-infra/prow/entrypoint"
+	"k8s.io/test-infra/prow/gcsupload"
+	"k8s.io/test-infra/prow/pod-utils/clone"
+	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+	"k8s.io/test-infra/prow/pod-utils/gcs"
+	"k8s.io/test-infra/prow/pod-utils/wrapper"
+)
+
+const (
+	// codeMountPath mirrors the path at which the decorated
+	// pod mounts the cloned code, so that working directories
+	// in the job's container can be translated to local ones.
+	codeMountPath = "/home/prow/go"
+	// localBucket is used as the bucket directory when the job
+	// does not configure where its artifacts would be uploaded.
+	localBucket = "local"
+)
+
+// localUpload knows how to write an object to a local path
+type localUpload func(dest string) error
+
+// fileUpload returns a localUpload which copies the file
+// on disk to the destination
+func fileUpload(src string) localUpload {
+	return func(dest string) error {
+		data, err := ioutil.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		return dataUpload(data)(dest)
+	}
+}
+
+// dataUpload returns a localUpload which writes the data
+// to the destination
+func dataUpload(data []byte) localUpload {
+	return func(dest string) error {
+		if err := os.MkdirAll(filepath.Dir(dest), os.ModePerm); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(dest, data, 0644)
+	}
+}
+
+// runResult describes the outcome of a local run
+type runResult struct {
+	// result is the value recorded in finished.json
+	result string
+	// path is the local directory holding the job's
+	// logs and artifacts
+	path string
+}
+
+// run emulates the decorated pod for the ProwJob. Refs are cloned under workDir,
+// the test container's command is executed with the entrypoint's timeout semantics
+// and all of the data the pod utilities would upload is written under outputDir
+// using the same layout the job would have in its GCS bucket.
+//
+// The test process inherits the environment of this process extended by the
+// downward API and container environment, and is run from the container's
+// working directory translated to the local clone.
+func run(pj prowapi.ProwJob, buildID, workDir, outputDir string) (*runResult, error) {
+	if pj.Spec.PodSpec == nil || len(pj.Spec.PodSpec.Containers) == 0 {
+		return nil, fmt.Errorf("prowjob %q lacks a pod spec", pj.Name)
+	}
+	decoration := pj.Spec.DecorationConfig
+	if decoration == nil {
+		logrus.Warn("ProwJob is not decorated, emulating default decoration.")
+		decoration = &prowapi.DecorationConfig{}
+	}
+
+	spec := downwardapi.NewJobSpec(pj.Spec, buildID, pj.Name)
+	bucket, gcsConfig := localBucket, &prowapi.GCSConfiguration{PathStrategy: prowapi.PathStrategyExplicit}
+	if decoration.GCSConfiguration != nil {
+		gcsConfig = decoration.GCSConfiguration.ApplyDefault(gcsConfig)
+		if gcsConfig.Bucket != "" {
+			bucket = gcsConfig.Bucket
+		}
+	}
+	jobBasePath, gcsPath, builder := gcsupload.PathsForJob(gcsConfig, &spec, "")
+
+	codeDir := filepath.Join(workDir, "code")
+	logDir := filepath.Join(workDir, "logs")
+	artifactDir := filepath.Join(logDir, "artifacts")
+	for _, dir := range []string{codeDir, artifactDir} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("could not create %s: %v", dir, err)
+		}
+	}
+
+	uploadTargets := map[string]localUpload{}
+	if alias := gcs.AliasForSpec(&spec); alias != "" {
+		uploadTargets[alias] = dataUpload([]byte("gs://" + path.Join(bucket, jobBasePath)))
+	}
+	for _, latestBuild := range gcs.LatestBuildForSpec(&spec, builder) {
+		uploadTargets[latestBuild] = dataUpload([]byte(buildID))
+	}
+	jobTargets := map[string]localUpload{}
+
+	records, cloneFailed := cloneRefs(pj, codeDir)
+	started := gcs.Started{
+		Timestamp:   time.Now().Unix(),
+		RepoVersion: downwardapi.GetRevisionFromSpec(&spec),
+		Repos:       map[string]string{},
+	}
+	if len(records) > 0 {
+		var cloneLog bytes.Buffer
+		for _, record := range records {
+			cloneLog.WriteString(clone.FormatRecord(record))
+			started.Repos[record.Refs.Org+"/"+record.Refs.Repo] = record.Refs.String()
+		}
+		if records[0].FinalSHA != "" {
+			started.RepoVersion = records[0].FinalSHA
+		}
+		recordData, err := json.Marshal(records)
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal clone records: %v", err)
+		}
+		jobTargets["clone-log.txt"] = dataUpload(cloneLog.Bytes())
+		jobTargets["clone-records.json"] = dataUpload(recordData)
+		if cloneFailed {
+			jobTargets["build-log.txt"] = dataUpload(cloneLog.Bytes())
+		}
+	}
+	if spec.Refs != nil && len(spec.Refs.Pulls) > 0 {
+		started.Pull = strconv.Itoa(spec.Refs.Pulls[0].Number)
+	}
+	startedData, err := json.Marshal(&started)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal starting data: %v", err)
+	}
+	jobTargets["started.json"] = dataUpload(startedData)
+
+	finished := gcs.Finished{Revision: downwardapi.GetRevisionFromSpec(&spec)}
+	passed := false
+	if cloneFailed {
+		finished.Result = "FAILURE"
+	} else {
+		options := entrypoint.Options{
+			ArtifactDir: artifactDir,
+			GracePeriod: decoration.GracePeriod,
+			Timeout:     decoration.Timeout,
+			Options: &wrapper.Options{
+				Args:         append(pj.Spec.PodSpec.Containers[0].Command, pj.Spec.PodSpec.Containers[0].Args...),
+				ProcessLog:   filepath.Join(logDir, "process-log.txt"),
+				MarkerFile:   filepath.Join(logDir, "marker-file.txt"),
+				MetadataFile: filepath.Join(artifactDir, "metadata.json"),
+			},
+		}
+		code, err := execute(pj, spec, options, codeDir)
+		if err != nil {
+			logrus.WithError(err).Error("Error executing test process")
+		}
+		passed = code == 0
+		switch {
+		case passed:
+			finished.Result = "SUCCESS"
+		case code == entrypoint.AbortedErrorCode:
+			finished.Result = "ABORTED"
+		default:
+			finished.Result = "FAILURE"
+		}
+		if metadata, err := readMetadata(options.MetadataFile); err != nil {
+			logrus.WithError(err).Warn("Could not read job metadata.")
+		} else {
+			finished.Metadata = metadata
+		}
+		jobTargets["build-log.txt"] = fileUpload(options.ProcessLog)
+		gatherArtifacts(artifactDir, jobTargets)
+	}
+
+	now := time.Now().Unix()
+	finished.Timestamp = &now
+	finished.Passed = &passed
+	finishedData, err := json.Marshal(&finished)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal finishing data: %v", err)
+	}
+	jobTargets["finished.json"] = dataUpload(finishedData)
+
+	for dest, upload := range jobTargets {
+		uploadTargets[path.Join(gcsPath, dest)] = upload
+	}
+	root := filepath.Join(outputDir, bucket)
+	if err := write(root, uploadTargets); err != nil {
+		return nil, err
+	}
+
+	return &runResult{result: finished.Result, path: filepath.Join(root, filepath.FromSlash(gcsPath))}, nil
+}
+
+// cloneRefs clones all refs for the job under codeDir as clonerefs would.
+// Credentials from the decoration config are not available locally, so the
+// local git configuration is used to fetch private refs.
+func cloneRefs(pj prowapi.ProwJob, codeDir string) ([]clone.Record, bool) {
+	if pj.Spec.DecorationConfig != nil {
+		if skip := pj.Spec.DecorationConfig.SkipCloning; skip != nil && *skip {
+			return nil, false
+		}
+		if len(pj.Spec.DecorationConfig.SSHKeySecrets) > 0 || pj.Spec.DecorationConfig.CookiefileSecret != "" {
+			logrus.Warn("Clone credentials are configured for the job, but local git credentials will be used instead.")
+		}
+	}
+	var refs []prowapi.Refs
+	if pj.Spec.Refs != nil {
+		refs = append(refs, *pj.Spec.Refs)
+	}
+	refs = append(refs, pj.Spec.ExtraRefs...)
+
+	var records []clone.Record
+	var failed bool
+	for _, ref := range refs {
+		record := clone.Run(ref, codeDir, clonerefs.DefaultGitUserName, clonerefs.DefaultGitUserEmail, "", nil)
+		records = append(records, record)
+		failed = failed || record.Failed
+	}
+	return records, failed
+}
+
+// execute runs the test process with the environment and working directory
+// the decorated test container would have.
+func execute(pj prowapi.ProwJob, spec downwardapi.JobSpec, options entrypoint.Options, codeDir string) (int, error) {
+	env, err := downwardapi.EnvForSpec(spec)
+	if err != nil {
+		return entrypoint.InternalErrorCode, fmt.Errorf("could not resolve job environment: %v", err)
+	}
+	container := pj.Spec.PodSpec.Containers[0]
+	for _, e := range container.Env {
+		if e.ValueFrom != nil {
+			logrus.WithField("name", e.Name).Warn("Cannot resolve environment variable from a reference locally, skipping.")
+			continue
+		}
+		env[e.Name] = e.Value
+	}
+	env["ARTIFACTS"] = options.ArtifactDir
+	env["GOPATH"] = codeDir
+	for key, value := range env {
+		if err := os.Setenv(key, value); err != nil {
+			return entrypoint.InternalErrorCode, fmt.Errorf("could not set $%s: %v", key, err)
+		}
+	}
+
+	workingDir := container.WorkingDir
+	switch {
+	case strings.HasPrefix(workingDir, codeMountPath):
+		workingDir = filepath.Join(codeDir, strings.TrimPrefix(workingDir, codeMountPath))
+	case workingDir == "" && spec.Refs != nil:
+		workingDir = clone.PathForRefs(codeDir, *spec.Refs)
+	}
+	if workingDir != "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return entrypoint.InternalErrorCode, fmt.Errorf("could not determine working directory: %v", err)
+		}
+		if err := os.Chdir(workingDir); err != nil {
+			return entrypoint.InternalErrorCode, fmt.Errorf("could not change to working directory: %v", err)
+		}
+		defer os.Chdir(cwd)
+	}
+
+	return options.ExecuteProcess()
+}
+
+func readMetadata(metadataFile string) (map[string]interface{}, error) {
+	raw, err := ioutil.ReadFile(metadataFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	metadata := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %v", metadataFile, err)
+	}
+	return metadata, nil
+}
+
+// gatherArtifacts records every file in the artifact directory
+// for upload under the artifacts/ directory of the job
+func gatherArtifacts(artifactDir string, uploadTargets map[string]localUpload) {
+	filepath.Walk(artifactDir, func(fspath string, info os.FileInfo, err error) error {
+		if info == nil || info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(artifactDir, fspath)
+		if err != nil {
+			logrus.Warnf("Encountered error in relative path calculation for %s under %s: %v", fspath, artifactDir, err)
+			return nil
+		}
+		uploadTargets[path.Join("artifacts", filepath.ToSlash(relPath))] = fileUpload(fspath)
+		return nil
+	})
+}
+
+// write writes all upload targets under the root directory
+func write(root string, uploadTargets map[string]localUpload) error {
+	for dest, upload := range uploadTargets {
+		localPath := filepath.Join(root, filepath.FromSlash(dest))
+		if err := upload(localPath); err != nil {
+			return fmt.Errorf("could not write %s: %v", localPath, err)
+		}
+		logrus.WithField("dest", localPath).Debug("Wrote output")
+	}
+	return nil
+}
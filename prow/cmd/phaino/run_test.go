@@ -0,0 +1,190 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/pod-utils/gcs"
+)
+
+func TestOptions_Validate(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		input       options
+		expectedErr bool
+	}{
+		{
+			name: "all ok",
+			input: options{
+				prowJobPath: "somewhere",
+				outputDir:   "elsewhere",
+			},
+			expectedErr: false,
+		},
+		{
+			name:        "missing job",
+			input:       options{outputDir: "elsewhere"},
+			expectedErr: true,
+		},
+		{
+			name:        "missing output",
+			input:       options{prowJobPath: "somewhere"},
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.input.Validate()
+		if testCase.expectedErr && err == nil {
+			t.Errorf("%s: expected an error but got none", testCase.name)
+		}
+		if !testCase.expectedErr && err != nil {
+			t.Errorf("%s: expected no error but got one: %v", testCase.name, err)
+		}
+	}
+}
+
+func periodic(script string, timeout time.Duration) prowapi.ProwJob {
+	return prowapi.ProwJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-id"},
+		Spec: prowapi.ProwJobSpec{
+			Type: prowapi.PeriodicJob,
+			Job:  "some-job",
+			DecorationConfig: &prowapi.DecorationConfig{
+				Timeout:     timeout,
+				GracePeriod: 100 * time.Millisecond,
+				GCSConfiguration: &prowapi.GCSConfiguration{
+					Bucket:       "some-bucket",
+					PathStrategy: prowapi.PathStrategyExplicit,
+				},
+			},
+			PodSpec: &coreapi.PodSpec{
+				Containers: []coreapi.Container{{
+					Command: []string{"/bin/sh", "-c"},
+					Args:    []string{script},
+					Env:     []coreapi.EnvVar{{Name: "GREETING", Value: "hello"}},
+				}},
+			},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	var testCases = []struct {
+		name           string
+		job            prowapi.ProwJob
+		expectedResult string
+		expectedLog    string
+		expectedFiles  []string
+	}{
+		{
+			name:           "passing job writes logs and artifacts",
+			job:            periodic(`echo "$GREETING from $JOB_NAME"; echo data > "$ARTIFACTS/junit.xml"`, time.Minute),
+			expectedResult: "SUCCESS",
+			expectedLog:    "hello from some-job",
+			expectedFiles: []string{
+				"some-bucket/logs/some-job/latest-build.txt",
+				"some-bucket/logs/some-job/1/build-log.txt",
+				"some-bucket/logs/some-job/1/started.json",
+				"some-bucket/logs/some-job/1/finished.json",
+				"some-bucket/logs/some-job/1/artifacts/junit.xml",
+			},
+		},
+		{
+			name:           "failing job",
+			job:            periodic(`echo oops; exit 3`, time.Minute),
+			expectedResult: "FAILURE",
+			expectedLog:    "oops",
+			expectedFiles: []string{
+				"some-bucket/logs/some-job/1/build-log.txt",
+				"some-bucket/logs/some-job/1/finished.json",
+			},
+		},
+		{
+			name:           "job exceeding its timeout is terminated",
+			job:            periodic(`echo start; sleep 10`, 100*time.Millisecond),
+			expectedResult: "FAILURE",
+			expectedLog:    "start",
+			expectedFiles: []string{
+				"some-bucket/logs/some-job/1/build-log.txt",
+				"some-bucket/logs/some-job/1/finished.json",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tmpDir, err := ioutil.TempDir("", "phaino")
+			if err != nil {
+				t.Fatalf("could not create temp dir: %v", err)
+			}
+			defer os.RemoveAll(tmpDir)
+			defer os.Unsetenv("GREETING")
+			outputDir := filepath.Join(tmpDir, "output")
+
+			result, err := run(testCase.job, "1", filepath.Join(tmpDir, "work"), outputDir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.result != testCase.expectedResult {
+				t.Errorf("expected result %q, got %q", testCase.expectedResult, result.result)
+			}
+			if expected := filepath.Join(outputDir, "some-bucket/logs/some-job/1"); result.path != expected {
+				t.Errorf("expected path %q, got %q", expected, result.path)
+			}
+			for _, file := range testCase.expectedFiles {
+				if _, err := os.Stat(filepath.Join(outputDir, file)); err != nil {
+					t.Errorf("expected %s to be written: %v", file, err)
+				}
+			}
+
+			buildLog, err := ioutil.ReadFile(filepath.Join(result.path, "build-log.txt"))
+			if err != nil {
+				t.Fatalf("could not read build log: %v", err)
+			}
+			if !strings.Contains(string(buildLog), testCase.expectedLog) {
+				t.Errorf("expected build log to contain %q, got %q", testCase.expectedLog, string(buildLog))
+			}
+
+			raw, err := ioutil.ReadFile(filepath.Join(result.path, "finished.json"))
+			if err != nil {
+				t.Fatalf("could not read finished.json: %v", err)
+			}
+			var finished gcs.Finished
+			if err := json.Unmarshal(raw, &finished); err != nil {
+				t.Fatalf("could not unmarshal finished.json: %v", err)
+			}
+			if finished.Result != testCase.expectedResult {
+				t.Errorf("expected finished.json result %q, got %q", testCase.expectedResult, finished.Result)
+			}
+			if finished.Passed == nil || *finished.Passed != (testCase.expectedResult == "SUCCESS") {
+				t.Errorf("finished.json has incorrect passed field: %v", finished.Passed)
+			}
+		})
+	}
+}
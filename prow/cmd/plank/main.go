@@ -37,6 +37,7 @@ import (
 	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/metrics"
 	"k8s.io/test-infra/prow/plank"
+	"k8s.io/test-infra/prow/tracing"
 )
 
 type options struct {
@@ -102,6 +103,7 @@ func main() {
 	if err := configAgent.Start(o.configPath, o.jobConfigPath); err != nil {
 		logrus.WithError(err).Fatal("Error starting config agent.")
 	}
+	tracing.Start("plank", func() tracing.Config { return configAgent.Config().Tracing })
 	cfg := configAgent.Config
 
 	secretAgent := &secret.Agent{}
@@ -150,7 +152,7 @@ func main() {
 		go metrics.PushMetrics("plank", pushGateway.Endpoint, pushGateway.Interval)
 	}
 	// serve prometheus metrics.
-	go serve()
+	go serve(configAgent)
 	// gather metrics for the jobs handled by plank.
 	go gather(c)
 
@@ -173,10 +175,13 @@ func main() {
 	}
 }
 
-// serve starts a http server and serves prometheus metrics.
+// serve starts a http server and serves prometheus metrics,
+// the version of the loaded config and the log level.
 // Meant to be called inside a goroutine.
-func serve() {
+func serve(configAgent *config.Agent) {
 	http.Handle("/metrics", promhttp.Handler())
+	http.Handle("/config-version", configAgent.VersionHandler())
+	http.Handle("/log-level", logrusutil.LevelHandler())
 	logrus.WithError(http.ListenAndServe(":8080", nil)).Fatal("ListenAndServe returned.")
 }
 
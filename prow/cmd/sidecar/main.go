@@ -24,6 +24,7 @@ import (
 	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/pod-utils/options"
 	"k8s.io/test-infra/prow/sidecar"
+	"k8s.io/test-infra/prow/tracing"
 )
 
 func main() {
@@ -40,7 +41,9 @@ func main() {
 		logrusutil.NewDefaultFieldsFormatter(nil, logrus.Fields{"component": "sidecar"}),
 	)
 
+	finish := tracing.StartPodUtility("sidecar")
 	failures, err := o.Run(context.Background())
+	finish(err)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to report job status")
 	}
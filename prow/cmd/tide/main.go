@@ -175,6 +175,7 @@ func main() {
 	defer c.Shutdown()
 	http.Handle("/", c)
 	http.Handle("/history", c.History)
+	http.Handle("/config-version", configAgent.VersionHandler())
 	server := &http.Server{Addr: ":" + strconv.Itoa(o.port)}
 
 	// Push metrics to the configured prometheus pushgateway endpoint.
@@ -17,13 +17,47 @@ limitations under the License.
 package config
 
 import (
+	"encoding/json"
+	"net/http"
 	"os"
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	configVersionInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "prow_config_version_info",
+		Help: "Always 1, labeled with the version of the currently loaded config.",
+	}, []string{"version"})
+	configGeneration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "prow_config_generation",
+		Help: "Number of times a config was loaded since the component started.",
+	})
+	configLoadTime = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "prow_config_load_timestamp_seconds",
+		Help: "Unix time at which the current config was loaded.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(configVersionInfo)
+	prometheus.MustRegister(configGeneration)
+	prometheus.MustRegister(configLoadTime)
+}
+
+// VersionInfo describes the config currently loaded by an Agent.
+type VersionInfo struct {
+	// Version is the hash of the loaded configuration files.
+	Version string `json:"version"`
+	// Generation counts how many configs were loaded by the Agent.
+	Generation int `json:"generation"`
+	// Loaded is the time at which the config was loaded.
+	Loaded time.Time `json:"loaded"`
+}
+
 // Delta represents the before and after states of a Config change detected by the Agent.
 type Delta struct {
 	Before, After Config
@@ -37,6 +71,7 @@ type DeltaChan = chan<- Delta
 type Agent struct {
 	mut           sync.RWMutex // do not export Lock, etc methods
 	c             *Config
+	version       VersionInfo
 	subscriptions []DeltaChan
 }
 
@@ -129,6 +164,11 @@ func (ca *Agent) Set(c *Config) {
 	}
 	delta := Delta{oldConfig, *c}
 	ca.c = c
+	ca.version = VersionInfo{Version: c.Version(), Generation: ca.version.Generation + 1, Loaded: time.Now()}
+	configVersionInfo.Reset()
+	configVersionInfo.WithLabelValues(ca.version.Version).Set(1)
+	configGeneration.Set(float64(ca.version.Generation))
+	configLoadTime.Set(float64(ca.version.Loaded.Unix()))
 	for _, subscription := range ca.subscriptions {
 		go func(sub DeltaChan) { // wait a minute to send each event
 			end := time.NewTimer(time.Minute)
@@ -142,3 +182,21 @@ func (ca *Agent) Set(c *Config) {
 		}(subscription)
 	}
 }
+
+// VersionInfo returns the version of the currently loaded config.
+func (ca *Agent) VersionInfo() VersionInfo {
+	ca.mut.RLock()
+	defer ca.mut.RUnlock()
+	return ca.version
+}
+
+// VersionHandler serves the version of the currently loaded config
+// as JSON, so that rollouts can verify all components agree on it.
+func (ca *Agent) VersionHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ca.VersionInfo()); err != nil {
+			logrus.WithError(err).Error("Error writing config version.")
+		}
+	})
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configversion")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	prowConfig := filepath.Join(dir, "config.yaml")
+	jobConfig := filepath.Join(dir, "jobs")
+	if err := os.Mkdir(jobConfig, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	write := func(path, content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	load := func() string {
+		c, err := Load(prowConfig, jobConfig)
+		if err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+		return c.Version()
+	}
+	write(prowConfig, "log_level: info\n")
+	write(filepath.Join(jobConfig, "jobs.yaml"), "periodics:\n- name: job\n  interval: 1h\n  agent: jenkins\n")
+
+	first := load()
+	if first == "" {
+		t.Fatal("expected a config version to be set")
+	}
+	if second := load(); second != first {
+		t.Errorf("expected loading the same files to give the same version, got %q and %q", first, second)
+	}
+	write(filepath.Join(jobConfig, "jobs.yaml"), "periodics:\n- name: job\n  interval: 2h\n  agent: jenkins\n")
+	if changed := load(); changed == first {
+		t.Error("expected changing a job config file to change the version")
+	}
+}
+
+func TestAgentVersionHandler(t *testing.T) {
+	ca := &Agent{}
+	ca.Set(&Config{version: "first"})
+	ca.Set(&Config{version: "second"})
+
+	recorder := httptest.NewRecorder()
+	ca.VersionHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/config-version", nil))
+	var info VersionInfo
+	if err := json.Unmarshal(recorder.Body.Bytes(), &info); err != nil {
+		t.Fatalf("failed to unmarshal response %q: %v", recorder.Body.String(), err)
+	}
+	if info.Version != "second" || info.Generation != 2 || info.Loaded.IsZero() {
+		t.Errorf("expected the second config at generation 2, got %+v", info)
+	}
+}
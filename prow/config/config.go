@@ -20,8 +20,10 @@ package config
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
@@ -42,16 +44,29 @@ import (
 	buildapi "github.com/knative/build/pkg/apis/build/v1alpha1"
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config/org"
+	"k8s.io/test-infra/prow/errorutil"
 	"k8s.io/test-infra/prow/github"
 	"k8s.io/test-infra/prow/kube"
+	"k8s.io/test-infra/prow/logrusutil"
 	"k8s.io/test-infra/prow/pod-utils/decorate"
 	"k8s.io/test-infra/prow/pod-utils/downwardapi"
+	"k8s.io/test-infra/prow/tracing"
 )
 
 // Config is a read-only snapshot of the config.
 type Config struct {
 	JobConfig
 	ProwConfig
+
+	// version identifies the content of the files this config was loaded from
+	version string
+}
+
+// Version returns a hash of the content of the configuration files this
+// config was loaded from, which is identical for components that loaded
+// the same configuration.
+func (c *Config) Version() string {
+	return c.version
 }
 
 // JobConfig is config for all prow jobs
@@ -64,6 +79,16 @@ type JobConfig struct {
 
 	// Periodics are not associated with any repo.
 	Periodics []Periodic `json:"periodics,omitempty"`
+
+	// JobDefaults are inherited by all jobs that do not set the defaulted
+	// fields themselves. Defaults are configured globally, per org or per
+	// repo using '*', 'org' or 'org/repo' as key. The narrowest match takes
+	// precedence. Periodics inherit the defaults of their first extra ref.
+	JobDefaults map[string]JobDefaults `json:"job_defaults,omitempty"`
+
+	// JobTemplates generate jobs for every combination of the values of
+	// their matrix parameters. They are expanded when the config is loaded.
+	JobTemplates []JobTemplate `json:"job_templates,omitempty"`
 }
 
 // ProwConfig is config for all prow controllers
@@ -98,7 +123,8 @@ type ProwConfig struct {
 	//
 	// "debug", "info", "warn", "warning", "error", "fatal", "panic"
 	//
-	// Defaults to "info".
+	// Defaults to "info". The level can be overridden at runtime on
+	// the /log-level endpoint that components serve next to metrics.
 	LogLevel string `json:"log_level,omitempty"`
 
 	// PushGateway is a prometheus push gateway.
@@ -118,6 +144,14 @@ type ProwConfig struct {
 	// found, or have another generic issue. The default that will be used if this is not set
 	// is: https://github.com/kubernetes/test-infra/issues
 	StatusErrorLink string `json:"status_error_link,omitempty"`
+
+	// InRepoConfig configures for which repositories presubmits and
+	// postsubmits may be defined in the repository itself.
+	InRepoConfig InRepoConfig `json:"in_repo_config,omitempty"`
+
+	// Tracing configures where components send the spans tracing the
+	// handling of events and the execution of the jobs they trigger.
+	Tracing tracing.Config `json:"tracing,omitempty"`
 }
 
 // OwnersDirBlacklist is used to configure which directories to ignore when
@@ -321,6 +355,11 @@ type Deck struct {
 	ExternalAgentLogs []ExternalAgentLog `json:"external_agent_logs,omitempty"`
 	// Branding of the frontend
 	Branding *Branding `json:"branding,omitempty"`
+	// ConfigVersionURLs maps the names of components to the URLs at
+	// which they serve the version of their loaded config, usually
+	// http://<component>/config-version. Deck shows a banner when the
+	// components stay on a different config version than its own.
+	ConfigVersionURLs map[string]string `json:"config_version_urls,omitempty"`
 }
 
 // ExternalAgentLog ensures an external agent like Jenkins can expose
@@ -405,8 +444,10 @@ func loadConfig(prowConfig, jobConfig string) (*Config, error) {
 		return nil, fmt.Errorf("prowConfig cannot be a dir - %s", prowConfig)
 	}
 
+	// the version hashes the content of all files in the order they are loaded
+	versionHash := sha256.New()
 	var nc Config
-	if err := yamlToConfig(prowConfig, &nc); err != nil {
+	if err := yamlToConfig(prowConfig, &nc, versionHash); err != nil {
 		return nil, err
 	}
 	if err := parseProwConfig(&nc); err != nil {
@@ -416,6 +457,7 @@ func loadConfig(prowConfig, jobConfig string) (*Config, error) {
 	// TODO(krzyzacy): temporary allow empty jobconfig
 	//                 also temporary allow job config in prow config
 	if jobConfig == "" {
+		nc.version = fmt.Sprintf("%x", versionHash.Sum(nil))
 		return &nc, nil
 	}
 
@@ -427,12 +469,13 @@ func loadConfig(prowConfig, jobConfig string) (*Config, error) {
 	if !stat.IsDir() {
 		// still support a single file
 		var jc JobConfig
-		if err := yamlToConfig(jobConfig, &jc); err != nil {
+		if err := yamlToConfig(jobConfig, &jc, versionHash); err != nil {
 			return nil, err
 		}
 		if err := nc.mergeJobConfig(jc); err != nil {
 			return nil, err
 		}
+		nc.version = fmt.Sprintf("%x", versionHash.Sum(nil))
 		return &nc, nil
 	}
 
@@ -440,6 +483,8 @@ func loadConfig(prowConfig, jobConfig string) (*Config, error) {
 	// since updateconfig plugin will use basename as a key in the configmap
 	uniqueBasenames := sets.String{}
 
+	// collect errors decoding files so that all of them are reported at once
+	var decodeErrs []error
 	err = filepath.Walk(jobConfig, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			logrus.WithError(err).Errorf("walking path %q.", path)
@@ -471,8 +516,9 @@ func loadConfig(prowConfig, jobConfig string) (*Config, error) {
 		uniqueBasenames.Insert(base)
 
 		var subConfig JobConfig
-		if err := yamlToConfig(path, &subConfig); err != nil {
-			return err
+		if err := yamlToConfig(path, &subConfig, versionHash); err != nil {
+			decodeErrs = append(decodeErrs, err)
+			return nil
 		}
 		return nc.mergeJobConfig(subConfig)
 	})
@@ -480,16 +526,25 @@ func loadConfig(prowConfig, jobConfig string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(decodeErrs) > 0 {
+		return nil, errorutil.NewAggregate(decodeErrs...)
+	}
+	nc.version = fmt.Sprintf("%x", versionHash.Sum(nil))
 
 	return &nc, nil
 }
 
-// yamlToConfig converts a yaml file into a Config object
-func yamlToConfig(path string, nc interface{}) error {
+// yamlToConfig converts a yaml file into a Config object and
+// adds its name and content to the version hash
+func yamlToConfig(path string, nc interface{}, versionHash io.Writer) error {
 	b, err := ReadFileMaybeGZIP(path)
 	if err != nil {
 		return fmt.Errorf("error reading %s: %v", path, err)
 	}
+	fmt.Fprintf(versionHash, "%s\x00%s\x00", filepath.Base(path), b)
+	if err := checkUnknownFields(path, b, nc); err != nil {
+		return err
+	}
 	if err := yaml.Unmarshal(b, nc); err != nil {
 		return fmt.Errorf("error unmarshaling %s: %v", path, err)
 	}
@@ -500,6 +555,9 @@ func yamlToConfig(path string, nc interface{}) error {
 	case *Config:
 		jc = &v.JobConfig
 	}
+	if err := jc.expandJobTemplates(); err != nil {
+		return fmt.Errorf("error expanding job templates in %s: %v", path, err)
+	}
 	for rep := range jc.Presubmits {
 		var fix func(*Presubmit)
 		fix = func(job *Presubmit) {
@@ -572,6 +630,17 @@ func (c *Config) mergeJobConfig(jc JobConfig) error {
 		}
 	}
 
+	// *** Job defaults ***
+	for identifier, defaults := range jc.JobDefaults {
+		if _, duplicated := c.JobDefaults[identifier]; duplicated {
+			return fmt.Errorf("duplicated job defaults for %q", identifier)
+		}
+		if c.JobDefaults == nil {
+			c.JobDefaults = map[string]JobDefaults{}
+		}
+		c.JobDefaults[identifier] = defaults
+	}
+
 	// *** Periodics ***
 	c.Periodics = append(c.Periodics, jc.Periodics...)
 
@@ -614,6 +683,13 @@ func setPeriodicDecorationDefaults(c *Config, ps *Periodic) {
 
 // finalizeJobConfig mutates and fixes entries for jobspecs
 func (c *Config) finalizeJobConfig() error {
+	for identifier := range c.JobDefaults {
+		if err := validateOrgRepoIdentifier(identifier); err != nil {
+			return fmt.Errorf("job_defaults: %v", err)
+		}
+	}
+	c.applyJobDefaults()
+
 	if c.decorationRequested() {
 		if c.Plank.DefaultDecorationConfig == nil {
 			return errors.New("no default decoration config provided for plank")
@@ -714,6 +790,9 @@ func validateJobBase(v JobBase, jobType prowapi.ProwJobType, podNamespace string
 	if err := validateLabels(v.Labels); err != nil {
 		return err
 	}
+	if err := validateTestgridAnnotations(v.Annotations); err != nil {
+		return err
+	}
 	if v.Spec == nil || len(v.Spec.Containers) == 0 {
 		return nil // knative-build and jenkins jobs have no spec
 	}
@@ -836,6 +915,16 @@ func parseProwConfig(c *Config) error {
 		c.Gerrit.RateLimit = 5
 	}
 
+	for identifier := range c.InRepoConfig.Enabled {
+		if err := validateOrgRepoIdentifier(identifier); err != nil {
+			return fmt.Errorf("in_repo_config.enabled: %v", err)
+		}
+	}
+
+	if err := c.Tracing.Validate(); err != nil {
+		return fmt.Errorf("tracing: %v", err)
+	}
+
 	if len(c.GitHubReporter.JobTypesToReport) == 0 {
 		// TODO(krzyzacy): The default will be changed to presubmit + postsubmit by April.
 		c.GitHubReporter.JobTypesToReport = append(c.GitHubReporter.JobTypesToReport, prowapi.PresubmitJob)
@@ -1041,7 +1130,7 @@ func parseProwConfig(c *Config) error {
 	if err != nil {
 		return err
 	}
-	logrus.SetLevel(lvl)
+	logrusutil.SetConfiguredLevel(lvl)
 
 	return nil
 }
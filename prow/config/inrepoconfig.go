@@ -0,0 +1,313 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	lru "github.com/hashicorp/golang-lru"
+	"sigs.k8s.io/yaml"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/git"
+)
+
+const (
+	// inRepoConfigFileName is the name of the file holding in-repo job config
+	inRepoConfigFileName = ".prow.yaml"
+	// inRepoConfigDirName is the name of the directory holding in-repo job config,
+	// which may be used instead of a single file
+	inRepoConfigDirName = ".prow"
+	// inRepoConfigCacheSize is the number of fetched in-repo configs we remember
+	inRepoConfigCacheSize = 1000
+)
+
+// InRepoConfig to enable configuration inside the source code of a repository
+type InRepoConfig struct {
+	// Enabled describes whether InRepoConfig is enabled for a given repository.
+	// This can be set globally, per org or per repo using '*', 'org' or
+	// 'org/repo' as key. The narrowest match always takes precedence.
+	Enabled map[string]*bool `json:"enabled,omitempty"`
+}
+
+// InRepoConfigEnabled returns whether in-repo config is enabled for the given
+// repository, identified as "org/repo".
+func (c *ProwConfig) InRepoConfigEnabled(identifier string) bool {
+	enabled := c.InRepoConfig.Enabled
+	if enabled[identifier] != nil {
+		return *enabled[identifier]
+	}
+	if idx := strings.Index(identifier, "/"); idx > 0 {
+		if org := identifier[:idx]; enabled[org] != nil {
+			return *enabled[org]
+		}
+	}
+	if enabled["*"] != nil {
+		return *enabled["*"]
+	}
+	return false
+}
+
+// ProwYAML represents the job config that is stored in the tested repository
+// in a `.prow.yaml` file or in YAML files under a `.prow/` directory.
+type ProwYAML struct {
+	Presubmits  []Presubmit  `json:"presubmits,omitempty"`
+	Postsubmits []Postsubmit `json:"postsubmits,omitempty"`
+}
+
+// inRepoConfigFile is the raw content of one in-repo config file
+type inRepoConfigFile struct {
+	path string
+	data []byte
+}
+
+// prowYAMLFetcher fetches the raw in-repo config files for a repository at
+// the base SHA with all head SHAs merged in.
+type prowYAMLFetcher func(gc *git.Client, identifier, baseSHA string, headSHAs ...string) ([]inRepoConfigFile, error)
+
+// prowYAMLCache holds the files fetched for a set of SHAs. The content of a
+// commit never changes, so cached entries never need to be invalidated.
+var prowYAMLCache, _ = lru.New(inRepoConfigCacheSize)
+
+// fetchProwYAML is replaced in tests to avoid cloning repositories
+var fetchProwYAML prowYAMLFetcher = fetchProwYAMLFiles
+
+// fetchProwYAMLFiles clones the repository, merges the head SHAs into the
+// base SHA and reads the in-repo config files.
+func fetchProwYAMLFiles(gc *git.Client, identifier, baseSHA string, headSHAs ...string) ([]inRepoConfigFile, error) {
+	if gc == nil {
+		return nil, errors.New("no git client configured to fetch in-repo config")
+	}
+	repo, err := gc.Clone(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone repo for %q: %v", identifier, err)
+	}
+	defer repo.Clean()
+
+	if err := repo.Checkout(baseSHA); err != nil {
+		return nil, err
+	}
+	// merge commits are never pushed, but git refuses to create them without an identity
+	if err := repo.Config("user.name", "prow"); err != nil {
+		return nil, err
+	}
+	if err := repo.Config("user.email", "prow@localhost"); err != nil {
+		return nil, err
+	}
+	for _, head := range headSHAs {
+		merged, err := repo.Merge(head)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %v", head, err)
+		}
+		if !merged {
+			return nil, fmt.Errorf("failed to merge %s: merge conflict", head)
+		}
+	}
+	return readProwYAMLFiles(repo.Dir)
+}
+
+// readProwYAMLFiles reads the in-repo config files from a checkout. It is not
+// an error for a repository not to have any in-repo config.
+func readProwYAMLFiles(dir string) ([]inRepoConfigFile, error) {
+	file := filepath.Join(dir, inRepoConfigFileName)
+	configDir := filepath.Join(dir, inRepoConfigDirName)
+	fileInfo, fileErr := os.Stat(file)
+	dirInfo, dirErr := os.Stat(configDir)
+	if fileErr == nil && dirErr == nil {
+		return nil, fmt.Errorf("both %s and %s/ exist, only one may be used", inRepoConfigFileName, inRepoConfigDirName)
+	}
+
+	var paths []string
+	switch {
+	case fileErr == nil && !fileInfo.IsDir():
+		paths = append(paths, file)
+	case dirErr == nil && dirInfo.IsDir():
+		err := filepath.Walk(configDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && (filepath.Ext(path) == ".yaml" || filepath.Ext(path) == ".yml") {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %v", inRepoConfigDirName, err)
+		}
+	case fileErr != nil && !os.IsNotExist(fileErr):
+		return nil, fmt.Errorf("failed to stat %s: %v", inRepoConfigFileName, fileErr)
+	case dirErr != nil && !os.IsNotExist(dirErr):
+		return nil, fmt.Errorf("failed to stat %s: %v", inRepoConfigDirName, dirErr)
+	}
+
+	sort.Strings(paths)
+	var files []inRepoConfigFile
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, inRepoConfigFile{path: relPath, data: data})
+	}
+	return files, nil
+}
+
+func prowYAMLCacheKey(identifier, baseSHA string, headSHAs []string) string {
+	return strings.Join(append([]string{identifier, baseSHA}, headSHAs...), ",")
+}
+
+// GetProwYAML fetches, defaults and validates the in-repo config of the given
+// repository for the base SHA with all head SHAs merged in. Fetched files are
+// cached by SHA, but are defaulted and validated against this Config on every
+// call. An empty ProwYAML is returned if in-repo config is not enabled for
+// the repository.
+func (c *Config) GetProwYAML(gc *git.Client, identifier, baseSHA string, headSHAs ...string) (*ProwYAML, error) {
+	prowYAML := &ProwYAML{}
+	if !c.InRepoConfigEnabled(identifier) {
+		return prowYAML, nil
+	}
+
+	key := prowYAMLCacheKey(identifier, baseSHA, headSHAs)
+	var files []inRepoConfigFile
+	if cached, ok := prowYAMLCache.Get(key); ok {
+		files = cached.([]inRepoConfigFile)
+	} else {
+		var err error
+		if files, err = fetchProwYAML(gc, identifier, baseSHA, headSHAs...); err != nil {
+			return nil, err
+		}
+		prowYAMLCache.Add(key, files)
+	}
+
+	for _, file := range files {
+		var fileConfig ProwYAML
+		if err := yaml.Unmarshal(file.data, &fileConfig); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %v", file.path, err)
+		}
+		for i := range fileConfig.Presubmits {
+			fileConfig.Presubmits[i].SourcePath = file.path
+		}
+		for i := range fileConfig.Postsubmits {
+			fileConfig.Postsubmits[i].SourcePath = file.path
+		}
+		prowYAML.Presubmits = append(prowYAML.Presubmits, fileConfig.Presubmits...)
+		prowYAML.Postsubmits = append(prowYAML.Postsubmits, fileConfig.Postsubmits...)
+	}
+
+	if err := c.defaultAndValidateProwYAML(prowYAML, identifier); err != nil {
+		return nil, fmt.Errorf("invalid in-repo config for %s: %v", identifier, err)
+	}
+	return prowYAML, nil
+}
+
+// defaultAndValidateProwYAML applies the same defaulting and validation to
+// in-repo jobs as is applied to centrally configured jobs, and ensures that
+// in-repo jobs do not collide with centrally configured ones.
+func (c *Config) defaultAndValidateProwYAML(p *ProwYAML, identifier string) error {
+	defaults := c.JobDefaultsFor(identifier)
+	for i := range p.Presubmits {
+		defaults.applyTo(&p.Presubmits[i].JobBase)
+	}
+	for i := range p.Postsubmits {
+		defaults.applyTo(&p.Postsubmits[i].JobBase)
+	}
+	for i := range p.Presubmits {
+		if p.Presubmits[i].Decorate && c.Plank.DefaultDecorationConfig == nil {
+			return errors.New("no default decoration config provided for plank")
+		}
+		setPresubmitDecorationDefaults(c, &p.Presubmits[i])
+	}
+	for i := range p.Postsubmits {
+		if p.Postsubmits[i].Decorate && c.Plank.DefaultDecorationConfig == nil {
+			return errors.New("no default decoration config provided for plank")
+		}
+		setPostsubmitDecorationDefaults(c, &p.Postsubmits[i])
+	}
+
+	c.defaultPresubmitFields(p.Presubmits)
+	if err := SetPresubmitRegexes(p.Presubmits); err != nil {
+		return fmt.Errorf("could not set regex: %v", err)
+	}
+	c.defaultPostsubmitFields(p.Postsubmits)
+	if err := SetPostsubmitRegexes(p.Postsubmits); err != nil {
+		return fmt.Errorf("could not set regex: %v", err)
+	}
+
+	allPresubmits := append(append([]Presubmit{}, c.Presubmits[identifier]...), p.Presubmits...)
+	for i, job := range p.Presubmits {
+		if err := resolvePresets(job.Name, job.Labels, job.Spec, job.BuildSpec, c.Presets); err != nil {
+			return err
+		}
+		if err := validateJobBase(job.JobBase, prowapi.PresubmitJob, c.PodNamespace); err != nil {
+			return fmt.Errorf("invalid presubmit job %s: %v", job.Name, err)
+		}
+		if err := validateTriggering(job); err != nil {
+			return err
+		}
+		for _, other := range allPresubmits[:len(c.Presubmits[identifier])+i] {
+			if other.Name == job.Name && other.Brancher.Intersects(job.Brancher) {
+				return fmt.Errorf("duplicated presubmit job: %s", job.Name)
+			}
+		}
+	}
+
+	allPostsubmits := append(append([]Postsubmit{}, c.Postsubmits[identifier]...), p.Postsubmits...)
+	for i, job := range p.Postsubmits {
+		if err := resolvePresets(job.Name, job.Labels, job.Spec, job.BuildSpec, c.Presets); err != nil {
+			return err
+		}
+		if err := validateJobBase(job.JobBase, prowapi.PostsubmitJob, c.PodNamespace); err != nil {
+			return fmt.Errorf("invalid postsubmit job %s: %v", job.Name, err)
+		}
+		for _, other := range allPostsubmits[:len(c.Postsubmits[identifier])+i] {
+			if other.Name == job.Name && other.Brancher.Intersects(job.Brancher) {
+				return fmt.Errorf("duplicated postsubmit job: %s", job.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// GetPresubmits returns the centrally configured presubmits for the repository
+// along with any presubmits configured in the repository itself.
+func (c *Config) GetPresubmits(gc *git.Client, identifier, baseSHA string, headSHAs ...string) ([]Presubmit, error) {
+	prowYAML, err := c.GetProwYAML(gc, identifier, baseSHA, headSHAs...)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]Presubmit{}, c.Presubmits[identifier]...), prowYAML.Presubmits...), nil
+}
+
+// GetPostsubmits returns the centrally configured postsubmits for the repository
+// along with any postsubmits configured in the repository itself.
+func (c *Config) GetPostsubmits(gc *git.Client, identifier, baseSHA string, headSHAs ...string) ([]Postsubmit, error) {
+	prowYAML, err := c.GetProwYAML(gc, identifier, baseSHA, headSHAs...)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]Postsubmit{}, c.Postsubmits[identifier]...), prowYAML.Postsubmits...), nil
+}
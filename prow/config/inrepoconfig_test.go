@@ -0,0 +1,267 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/test-infra/prow/git"
+	"k8s.io/test-infra/prow/git/localgit"
+)
+
+func TestInRepoConfigEnabled(t *testing.T) {
+	yes, no := true, false
+	testCases := []struct {
+		name       string
+		config     InRepoConfig
+		identifier string
+		expected   bool
+	}{
+		{
+			name:       "unconfigured is disabled",
+			identifier: "org/repo",
+		},
+		{
+			name:       "enabled globally",
+			config:     InRepoConfig{Enabled: map[string]*bool{"*": &yes}},
+			identifier: "org/repo",
+			expected:   true,
+		},
+		{
+			name:       "enabled for org",
+			config:     InRepoConfig{Enabled: map[string]*bool{"org": &yes}},
+			identifier: "org/repo",
+			expected:   true,
+		},
+		{
+			name:       "enabled for other org",
+			config:     InRepoConfig{Enabled: map[string]*bool{"other": &yes}},
+			identifier: "org/repo",
+		},
+		{
+			name:       "repo disable overrides org enable",
+			config:     InRepoConfig{Enabled: map[string]*bool{"org": &yes, "org/repo": &no}},
+			identifier: "org/repo",
+		},
+		{
+			name:       "org enable overrides global disable",
+			config:     InRepoConfig{Enabled: map[string]*bool{"*": &no, "org": &yes}},
+			identifier: "org/repo",
+			expected:   true,
+		},
+	}
+	for _, tc := range testCases {
+		if actual := (&ProwConfig{InRepoConfig: tc.config}).InRepoConfigEnabled(tc.identifier); actual != tc.expected {
+			t.Errorf("%s: expected enabled to be %t, got %t", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestReadProwYAMLFiles(t *testing.T) {
+	testCases := []struct {
+		name          string
+		files         map[string]string
+		expectedPaths []string
+		expectedErr   bool
+	}{
+		{
+			name: "no in-repo config",
+		},
+		{
+			name:          "single file",
+			files:         map[string]string{".prow.yaml": "presubmits: []"},
+			expectedPaths: []string{".prow.yaml"},
+		},
+		{
+			name: "directory of files",
+			files: map[string]string{
+				".prow/b.yaml":       "presubmits: []",
+				".prow/a/nested.yml": "presubmits: []",
+				".prow/README.md":    "ignored",
+			},
+			expectedPaths: []string{".prow/a/nested.yml", ".prow/b.yaml"},
+		},
+		{
+			name: "both file and directory",
+			files: map[string]string{
+				".prow.yaml":   "presubmits: []",
+				".prow/a.yaml": "presubmits: []",
+			},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "inrepoconfig")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			for path, content := range tc.files {
+				path = filepath.Join(dir, path)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("failed to create dir: %v", err)
+				}
+				if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write file: %v", err)
+				}
+			}
+
+			files, err := readProwYAMLFiles(dir)
+			if tc.expectedErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.expectedErr, err)
+			}
+			var paths []string
+			for _, file := range files {
+				paths = append(paths, file.path)
+			}
+			if !reflect.DeepEqual(paths, tc.expectedPaths) {
+				t.Errorf("expected paths %v, got %v", tc.expectedPaths, paths)
+			}
+		})
+	}
+}
+
+func TestGetPresubmits(t *testing.T) {
+	yes := true
+	fetches := 0
+	files := map[string]string{}
+	fetchProwYAML = func(_ *git.Client, identifier, _ string, _ ...string) ([]inRepoConfigFile, error) {
+		fetches++
+		if content, ok := files[identifier]; ok {
+			return []inRepoConfigFile{{path: ".prow.yaml", data: []byte(content)}}, nil
+		}
+		return nil, nil
+	}
+	defer func() {
+		fetchProwYAML = fetchProwYAMLFiles
+		prowYAMLCache.Purge()
+	}()
+
+	central := Presubmit{JobBase: JobBase{Name: "central"}}
+	c := &Config{
+		JobConfig: JobConfig{Presubmits: map[string][]Presubmit{"org/repo": {central}, "org/disabled": {central}}},
+		ProwConfig: ProwConfig{
+			PodNamespace: "pods",
+			InRepoConfig: InRepoConfig{Enabled: map[string]*bool{"org": &yes, "org/disabled": new(bool)}},
+		},
+	}
+	spec := `
+  spec:
+    containers:
+    - image: alpine`
+	files["org/repo"] = `presubmits:
+- name: in-repo
+  always_run: true` + spec
+	files["org/duplicate"] = `presubmits:
+- name: central` + spec
+	files["org/invalid"] = `presubmits:
+- name: in-repo
+  run_if_changed: "["` + spec
+	c.Presubmits["org/duplicate"] = []Presubmit{central}
+
+	presubmits, err := c.GetPresubmits(nil, "org/repo", "base", "head")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, presubmit := range presubmits {
+		names = append(names, presubmit.Name)
+	}
+	if expected := []string{"central", "in-repo"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected presubmits %v, got %v", expected, names)
+	}
+	inRepo := presubmits[1]
+	if inRepo.Context != "in-repo" || inRepo.SourcePath != ".prow.yaml" || inRepo.Agent != "kubernetes" {
+		t.Errorf("expected in-repo presubmit to be defaulted, got %#v", inRepo)
+	}
+
+	if _, err := c.GetPresubmits(nil, "org/repo", "base", "head"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fetches != 1 {
+		t.Errorf("expected in-repo config to be cached, but it was fetched %d times", fetches)
+	}
+
+	presubmits, err = c.GetPresubmits(nil, "org/disabled", "base", "head")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(presubmits) != 1 || fetches != 1 {
+		t.Errorf("expected only central presubmits without fetching for a disabled repo, got %v", presubmits)
+	}
+
+	if _, err := c.GetPresubmits(nil, "org/duplicate", "base"); err == nil || !strings.Contains(err.Error(), "duplicated presubmit job") {
+		t.Errorf("expected an error for a duplicated job, got %v", err)
+	}
+	if _, err := c.GetPresubmits(nil, "org/invalid", "base"); err == nil {
+		t.Error("expected an error for an invalid run_if_changed regex")
+	}
+}
+
+func TestFetchProwYAMLFiles(t *testing.T) {
+	lg, gc, err := localgit.New()
+	if err != nil {
+		t.Fatalf("failed to make localgit: %v", err)
+	}
+	defer func() {
+		if err := lg.Clean(); err != nil {
+			t.Errorf("error cleaning localgit: %v", err)
+		}
+		if err := gc.Clean(); err != nil {
+			t.Errorf("error cleaning git client: %v", err)
+		}
+	}()
+	if err := lg.MakeFakeRepo("org", "repo"); err != nil {
+		t.Fatalf("failed to make fake repo: %v", err)
+	}
+	baseSHA, err := lg.RevParse("org", "repo", "HEAD")
+	if err != nil {
+		t.Fatalf("failed to get base SHA: %v", err)
+	}
+	if err := lg.CheckoutNewBranch("org", "repo", "pull"); err != nil {
+		t.Fatalf("failed to create branch: %v", err)
+	}
+	if err := lg.AddCommit("org", "repo", map[string][]byte{".prow.yaml": []byte("presubmits: []")}); err != nil {
+		t.Fatalf("failed to add commit: %v", err)
+	}
+	headSHA, err := lg.RevParse("org", "repo", "HEAD")
+	if err != nil {
+		t.Fatalf("failed to get head SHA: %v", err)
+	}
+
+	files, err := fetchProwYAMLFiles(gc, "org/repo", strings.TrimSpace(baseSHA))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected no in-repo config at the base SHA, got %v", files)
+	}
+
+	files, err = fetchProwYAMLFiles(gc, "org/repo", strings.TrimSpace(baseSHA), strings.TrimSpace(headSHA))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || files[0].path != ".prow.yaml" {
+		t.Errorf("expected in-repo config from the merged head SHA, got %v", files)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+)
+
+// JobDefaults holds values for job fields that jobs inherit unless
+// they set the field themselves.
+type JobDefaults struct {
+	// Labels are added to the labels of the job. Labels set on the
+	// job take precedence.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Cluster is the alias of the cluster to run the job in.
+	Cluster string `json:"cluster,omitempty"`
+	// DecorationConfig is merged into the decoration config of
+	// decorated jobs, which includes the job timeout.
+	DecorationConfig *prowapi.DecorationConfig `json:"decoration_config,omitempty"`
+	// Resources are set on containers in the job's pod spec that do
+	// not request or limit any resources themselves.
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// inherit returns these defaults with unset fields taken from the parent.
+func (d JobDefaults) inherit(parent JobDefaults) JobDefaults {
+	merged := JobDefaults{
+		Cluster:          d.Cluster,
+		DecorationConfig: d.DecorationConfig.ApplyDefault(parent.DecorationConfig),
+		Resources:        d.Resources,
+	}
+	if merged.Cluster == "" {
+		merged.Cluster = parent.Cluster
+	}
+	if merged.Resources == nil {
+		merged.Resources = parent.Resources
+	}
+	if len(d.Labels) > 0 || len(parent.Labels) > 0 {
+		merged.Labels = map[string]string{}
+		for k, v := range parent.Labels {
+			merged.Labels[k] = v
+		}
+		for k, v := range d.Labels {
+			merged.Labels[k] = v
+		}
+	}
+	return merged
+}
+
+// applyTo sets the defaults on all fields the job does not set itself.
+func (d JobDefaults) applyTo(job *JobBase) {
+	for k, v := range d.Labels {
+		if _, set := job.Labels[k]; set {
+			continue
+		}
+		if job.Labels == nil {
+			job.Labels = map[string]string{}
+		}
+		job.Labels[k] = v
+	}
+	if job.Cluster == "" {
+		job.Cluster = d.Cluster
+	}
+	if job.Decorate && d.DecorationConfig != nil {
+		job.DecorationConfig = job.DecorationConfig.ApplyDefault(d.DecorationConfig)
+	}
+	if d.Resources != nil && job.Spec != nil {
+		for i := range job.Spec.Containers {
+			resources := &job.Spec.Containers[i].Resources
+			if len(resources.Requests) == 0 && len(resources.Limits) == 0 {
+				*resources = *d.Resources.DeepCopy()
+			}
+		}
+	}
+}
+
+// JobDefaultsFor resolves the defaults for jobs of the given repository,
+// identified as "org/repo", from the global, org and repo level defaults.
+// More specific defaults take precedence. Pass an empty identifier to
+// resolve the global defaults only.
+func (c *JobConfig) JobDefaultsFor(identifier string) JobDefaults {
+	resolved := c.JobDefaults["*"]
+	if identifier == "" {
+		return resolved.inherit(JobDefaults{})
+	}
+	if idx := strings.Index(identifier, "/"); idx > 0 {
+		resolved = c.JobDefaults[identifier[:idx]].inherit(resolved)
+	}
+	return c.JobDefaults[identifier].inherit(resolved)
+}
+
+// periodicIdentifier determines the repository a periodic job is
+// associated with for the purpose of resolving defaults, if any.
+func periodicIdentifier(job Periodic) string {
+	if len(job.ExtraRefs) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", job.ExtraRefs[0].Org, job.ExtraRefs[0].Repo)
+}
+
+// applyJobDefaults sets the hierarchical job defaults on all jobs. This must
+// happen before any other defaulting so that inherited values are treated
+// like values set on the job itself.
+func (c *JobConfig) applyJobDefaults() {
+	if len(c.JobDefaults) == 0 {
+		return
+	}
+	for repo, jobs := range c.Presubmits {
+		defaults := c.JobDefaultsFor(repo)
+		for i := range jobs {
+			defaults.applyTo(&jobs[i].JobBase)
+		}
+	}
+	for repo, jobs := range c.Postsubmits {
+		defaults := c.JobDefaultsFor(repo)
+		for i := range jobs {
+			defaults.applyTo(&jobs[i].JobBase)
+		}
+	}
+	for i := range c.Periodics {
+		c.JobDefaultsFor(periodicIdentifier(c.Periodics[i])).applyTo(&c.Periodics[i].JobBase)
+	}
+}
+
+// validateOrgRepoIdentifier ensures a key of a map configuring settings
+// hierarchically is one of '*', 'org' or 'org/repo'.
+func validateOrgRepoIdentifier(identifier string) error {
+	if identifier == "*" {
+		return nil
+	}
+	parts := strings.Split(identifier, "/")
+	if len(parts) > 2 {
+		return fmt.Errorf("invalid key %q, must be '*', 'org' or 'org/repo'", identifier)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return fmt.Errorf("invalid key %q, must be '*', 'org' or 'org/repo'", identifier)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+)
+
+func TestJobDefaultsFor(t *testing.T) {
+	jc := &JobConfig{JobDefaults: map[string]JobDefaults{
+		"*": {
+			Labels:           map[string]string{"global": "true", "level": "global"},
+			Cluster:          "global",
+			DecorationConfig: &prowapi.DecorationConfig{Timeout: time.Hour, GracePeriod: time.Minute},
+		},
+		"org": {
+			Labels:           map[string]string{"level": "org"},
+			DecorationConfig: &prowapi.DecorationConfig{Timeout: 2 * time.Hour},
+		},
+		"org/repo": {
+			Labels:  map[string]string{"level": "repo"},
+			Cluster: "repo",
+		},
+	}}
+
+	testCases := []struct {
+		name       string
+		identifier string
+		expected   JobDefaults
+	}{
+		{
+			name: "global only",
+			expected: JobDefaults{
+				Labels:           map[string]string{"global": "true", "level": "global"},
+				Cluster:          "global",
+				DecorationConfig: &prowapi.DecorationConfig{Timeout: time.Hour, GracePeriod: time.Minute},
+			},
+		},
+		{
+			name:       "unconfigured org inherits global",
+			identifier: "other/repo",
+			expected: JobDefaults{
+				Labels:           map[string]string{"global": "true", "level": "global"},
+				Cluster:          "global",
+				DecorationConfig: &prowapi.DecorationConfig{Timeout: time.Hour, GracePeriod: time.Minute},
+			},
+		},
+		{
+			name:       "org overrides global",
+			identifier: "org/other",
+			expected: JobDefaults{
+				Labels:           map[string]string{"global": "true", "level": "org"},
+				Cluster:          "global",
+				DecorationConfig: &prowapi.DecorationConfig{Timeout: 2 * time.Hour, GracePeriod: time.Minute},
+			},
+		},
+		{
+			name:       "repo overrides org and global",
+			identifier: "org/repo",
+			expected: JobDefaults{
+				Labels:           map[string]string{"global": "true", "level": "repo"},
+				Cluster:          "repo",
+				DecorationConfig: &prowapi.DecorationConfig{Timeout: 2 * time.Hour, GracePeriod: time.Minute},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		if actual := jc.JobDefaultsFor(tc.identifier); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: got incorrect defaults: %s", tc.name, diff.ObjectReflectDiff(tc.expected, actual))
+		}
+	}
+}
+
+func TestApplyJobDefaults(t *testing.T) {
+	defaultResources := v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}
+	ownResources := v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}}
+	jc := &JobConfig{
+		JobDefaults: map[string]JobDefaults{
+			"*": {Cluster: "global"},
+			"org": {
+				Labels:           map[string]string{"team": "org", "tier": "default"},
+				DecorationConfig: &prowapi.DecorationConfig{Timeout: time.Hour},
+				Resources:        &defaultResources,
+			},
+		},
+		Presubmits: map[string][]Presubmit{"org/repo": {{JobBase: JobBase{
+			Name:          "decorated",
+			Labels:        map[string]string{"tier": "own"},
+			Cluster:       "own",
+			UtilityConfig: UtilityConfig{Decorate: true},
+			Spec:          &v1.PodSpec{Containers: []v1.Container{{Image: "inherits"}, {Image: "own", Resources: ownResources}}},
+		}}}},
+		Postsubmits: map[string][]Postsubmit{"org/repo": {{JobBase: JobBase{
+			Name:          "own-timeout",
+			UtilityConfig: UtilityConfig{Decorate: true, DecorationConfig: &prowapi.DecorationConfig{Timeout: time.Minute}},
+		}}}},
+		Periodics: []Periodic{
+			{JobBase: JobBase{Name: "no-refs"}},
+			{JobBase: JobBase{Name: "refs", UtilityConfig: UtilityConfig{ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "repo"}}}}},
+		},
+	}
+	jc.applyJobDefaults()
+
+	presubmit := jc.Presubmits["org/repo"][0]
+	if expected := map[string]string{"team": "org", "tier": "own"}; !reflect.DeepEqual(presubmit.Labels, expected) {
+		t.Errorf("expected labels %v, got %v", expected, presubmit.Labels)
+	}
+	if presubmit.Cluster != "own" {
+		t.Errorf("expected cluster set on the job to be kept, got %q", presubmit.Cluster)
+	}
+	if presubmit.DecorationConfig == nil || presubmit.DecorationConfig.Timeout != time.Hour {
+		t.Errorf("expected decorated job to inherit the timeout, got %v", presubmit.DecorationConfig)
+	}
+	if !reflect.DeepEqual(presubmit.Spec.Containers[0].Resources, defaultResources) {
+		t.Errorf("expected container without resources to inherit them, got %v", presubmit.Spec.Containers[0].Resources)
+	}
+	if !reflect.DeepEqual(presubmit.Spec.Containers[1].Resources, ownResources) {
+		t.Errorf("expected container resources to be kept, got %v", presubmit.Spec.Containers[1].Resources)
+	}
+
+	if postsubmit := jc.Postsubmits["org/repo"][0]; postsubmit.DecorationConfig.Timeout != time.Minute || postsubmit.Cluster != "global" {
+		t.Errorf("expected postsubmit to keep its timeout and inherit the global cluster, got %v and %q", postsubmit.DecorationConfig, postsubmit.Cluster)
+	}
+	if periodic := jc.Periodics[0]; periodic.Labels != nil || periodic.Cluster != "global" {
+		t.Errorf("expected periodic without refs to inherit only global defaults, got %v", periodic.JobBase)
+	}
+	if periodic := jc.Periodics[1]; periodic.Labels["team"] != "org" {
+		t.Errorf("expected periodic to inherit the defaults of its extra ref, got %v", periodic.Labels)
+	}
+}
+
+func TestValidateOrgRepoIdentifier(t *testing.T) {
+	for identifier, valid := range map[string]bool{
+		"*":          true,
+		"org":        true,
+		"org/repo":   true,
+		"":           false,
+		"org/":       false,
+		"/repo":      false,
+		"org/repo/x": false,
+	} {
+		if err := validateOrgRepoIdentifier(identifier); valid != (err == nil) {
+			t.Errorf("%q: expected valid to be %t, got error %v", identifier, valid, err)
+		}
+	}
+}
@@ -82,6 +82,10 @@ type JobBase struct {
 	Name string `json:"name"`
 	// Labels are added to prowjobs and pods created for this job.
 	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are unused by prow itself, but provide a space to configure
+	// other automation, e.g. the testgrid-* annotations read by the testgrid
+	// configurator.
+	Annotations map[string]string `json:"annotations,omitempty"`
 	// MaximumConcurrency of this job, 0 implies no limit.
 	MaxConcurrency int `json:"max_concurrency,omitempty"`
 	// Agent that will take care of running this job.
@@ -100,6 +104,9 @@ type JobBase struct {
 	ErrorOnEviction bool `json:"error_on_eviction,omitempty"`
 	// SourcePath contains the path where this job is defined
 	SourcePath string `json:"-"`
+	// MatrixValues are the values of the matrix parameters this job was
+	// generated with, if it was generated from a job template
+	MatrixValues map[string]string `json:"-"`
 	// Spec is the Kubernetes pod spec used if Agent is kubernetes.
 	Spec *v1.PodSpec `json:"spec,omitempty"`
 	// BuildSpec is the Knative build spec used if Agent is knative-build.
@@ -0,0 +1,219 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// JobTemplate generates one set of jobs for every combination of the
+// values of its matrix parameters. Parameters are referenced in the jobs
+// as {{.parameter}} and may be used in any string, including repo names.
+type JobTemplate struct {
+	// Matrix maps parameter names to the values they take.
+	Matrix map[string][]string `json:"matrix"`
+	// Exclude lists combinations no jobs are generated for. A combination
+	// is excluded if it matches all parameters of any of the entries.
+	Exclude []map[string]string `json:"exclude,omitempty"`
+
+	Presubmits  map[string][]Presubmit  `json:"presubmits,omitempty"`
+	Postsubmits map[string][]Postsubmit `json:"postsubmits,omitempty"`
+	Periodics   []Periodic              `json:"periodics,omitempty"`
+}
+
+// templatedJobs are the jobs of a template, in the form
+// they are rendered for every combination of values
+type templatedJobs struct {
+	Presubmits  map[string][]Presubmit  `json:"presubmits,omitempty"`
+	Postsubmits map[string][]Postsubmit `json:"postsubmits,omitempty"`
+	Periodics   []Periodic              `json:"periodics,omitempty"`
+}
+
+var matrixParameterRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func (t *JobTemplate) validate() error {
+	if len(t.Matrix) == 0 {
+		return fmt.Errorf("no matrix parameters configured")
+	}
+	for parameter, values := range t.Matrix {
+		if !matrixParameterRegex.MatchString(parameter) {
+			return fmt.Errorf("invalid matrix parameter %q, must match %s", parameter, matrixParameterRegex)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("matrix parameter %q has no values", parameter)
+		}
+	}
+	for _, exclude := range t.Exclude {
+		for parameter := range exclude {
+			if _, ok := t.Matrix[parameter]; !ok {
+				return fmt.Errorf("exclude references unknown matrix parameter %q", parameter)
+			}
+		}
+	}
+	return nil
+}
+
+// combinations lists all combinations of parameter values that are not
+// excluded. The order is deterministic: parameters are sorted by name and
+// the last one varies fastest, taking its values in the configured order.
+func (t *JobTemplate) combinations() []map[string]string {
+	var parameters []string
+	for parameter := range t.Matrix {
+		parameters = append(parameters, parameter)
+	}
+	sort.Strings(parameters)
+
+	combinations := []map[string]string{{}}
+	for _, parameter := range parameters {
+		var extended []map[string]string
+		for _, combination := range combinations {
+			for _, value := range t.Matrix[parameter] {
+				next := map[string]string{parameter: value}
+				for k, v := range combination {
+					next[k] = v
+				}
+				extended = append(extended, next)
+			}
+		}
+		combinations = extended
+	}
+
+	var included []map[string]string
+	for _, combination := range combinations {
+		if !t.excludes(combination) {
+			included = append(included, combination)
+		}
+	}
+	return included
+}
+
+func (t *JobTemplate) excludes(combination map[string]string) bool {
+	for _, exclude := range t.Exclude {
+		matches := true
+		for parameter, value := range exclude {
+			if combination[parameter] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// expand renders the jobs of the template for every combination of values
+func (t *JobTemplate) expand() (*templatedJobs, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(templatedJobs{Presubmits: t.Presubmits, Postsubmits: t.Postsubmits, Periodics: t.Periodics})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal jobs: %v", err)
+	}
+	tmpl, err := template.New("jobs").Option("missingkey=error").Parse(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse jobs as a template: %v", err)
+	}
+
+	expanded := &templatedJobs{}
+	for _, combination := range t.combinations() {
+		// values are rendered into JSON strings, so they need to be escaped
+		escaped := map[string]string{}
+		for parameter, value := range combination {
+			quoted, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			escaped[parameter] = string(quoted[1 : len(quoted)-1])
+		}
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, escaped); err != nil {
+			return nil, fmt.Errorf("could not render jobs for %s: %v", describeCombination(combination), err)
+		}
+		var jobs templatedJobs
+		if err := json.Unmarshal(rendered.Bytes(), &jobs); err != nil {
+			return nil, fmt.Errorf("could not unmarshal jobs rendered for %s: %v", describeCombination(combination), err)
+		}
+
+		for repo, presubmits := range jobs.Presubmits {
+			for i := range presubmits {
+				presubmits[i].MatrixValues = combination
+			}
+			if expanded.Presubmits == nil {
+				expanded.Presubmits = map[string][]Presubmit{}
+			}
+			expanded.Presubmits[repo] = append(expanded.Presubmits[repo], presubmits...)
+		}
+		for repo, postsubmits := range jobs.Postsubmits {
+			for i := range postsubmits {
+				postsubmits[i].MatrixValues = combination
+			}
+			if expanded.Postsubmits == nil {
+				expanded.Postsubmits = map[string][]Postsubmit{}
+			}
+			expanded.Postsubmits[repo] = append(expanded.Postsubmits[repo], postsubmits...)
+		}
+		for i := range jobs.Periodics {
+			jobs.Periodics[i].MatrixValues = combination
+		}
+		expanded.Periodics = append(expanded.Periodics, jobs.Periodics...)
+	}
+	return expanded, nil
+}
+
+func describeCombination(combination map[string]string) string {
+	var pairs []string
+	for parameter, value := range combination {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", parameter, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// expandJobTemplates adds the jobs generated from all job templates to
+// the job config and removes the templates.
+func (c *JobConfig) expandJobTemplates() error {
+	for i := range c.JobTemplates {
+		expanded, err := c.JobTemplates[i].expand()
+		if err != nil {
+			return fmt.Errorf("job_templates[%d]: %v", i, err)
+		}
+		for repo, presubmits := range expanded.Presubmits {
+			if c.Presubmits == nil {
+				c.Presubmits = map[string][]Presubmit{}
+			}
+			c.Presubmits[repo] = append(c.Presubmits[repo], presubmits...)
+		}
+		for repo, postsubmits := range expanded.Postsubmits {
+			if c.Postsubmits == nil {
+				c.Postsubmits = map[string][]Postsubmit{}
+			}
+			c.Postsubmits[repo] = append(c.Postsubmits[repo], postsubmits...)
+		}
+		c.Periodics = append(c.Periodics, expanded.Periodics...)
+	}
+	c.JobTemplates = nil
+	return nil
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJobTemplateCombinations(t *testing.T) {
+	template := JobTemplate{
+		Matrix: map[string][]string{
+			"version":  {"1.14", "1.13"},
+			"provider": {"gce", "aws"},
+		},
+		Exclude: []map[string]string{{"provider": "aws", "version": "1.13"}},
+	}
+	expected := []map[string]string{
+		{"provider": "gce", "version": "1.14"},
+		{"provider": "gce", "version": "1.13"},
+		{"provider": "aws", "version": "1.14"},
+	}
+	if actual := template.combinations(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected combinations %v, got %v", expected, actual)
+	}
+}
+
+func TestExpandJobTemplates(t *testing.T) {
+	testCases := []struct {
+		name        string
+		template    JobTemplate
+		presubmits  map[string][]string
+		periodics   []string
+		expectedErr bool
+	}{
+		{
+			name: "jobs are generated for every combination",
+			template: JobTemplate{
+				Matrix: map[string][]string{"version": {"1.13", "1.14"}, "provider": {"gce"}},
+				Presubmits: map[string][]Presubmit{"org/repo": {{
+					JobBase: JobBase{Name: "pull-e2e-{{.provider}}-{{.version}}"},
+				}}},
+				Periodics: []Periodic{{JobBase: JobBase{Name: "ci-e2e-{{.provider}}-{{.version}}"}}},
+			},
+			presubmits: map[string][]string{"org/repo": {"pull-e2e-gce-1.13", "pull-e2e-gce-1.14"}},
+			periodics:  []string{"ci-e2e-gce-1.13", "ci-e2e-gce-1.14"},
+		},
+		{
+			name: "parameters can be used in repo names",
+			template: JobTemplate{
+				Matrix: map[string][]string{"repo": {"a", "b"}},
+				Presubmits: map[string][]Presubmit{"org/{{.repo}}": {{
+					JobBase: JobBase{Name: "pull-{{.repo}}-verify"},
+				}}},
+			},
+			presubmits: map[string][]string{"org/a": {"pull-a-verify"}, "org/b": {"pull-b-verify"}},
+		},
+		{
+			name: "values are escaped",
+			template: JobTemplate{
+				Matrix:    map[string][]string{"quoted": {`"x"`}},
+				Periodics: []Periodic{{JobBase: JobBase{Name: "ci-{{.quoted}}"}}},
+			},
+			periodics: []string{`ci-"x"`},
+		},
+		{
+			name: "unknown parameters are rejected",
+			template: JobTemplate{
+				Matrix:    map[string][]string{"version": {"1.13"}},
+				Periodics: []Periodic{{JobBase: JobBase{Name: "ci-{{.verison}}"}}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid parameter names are rejected",
+			template: JobTemplate{
+				Matrix: map[string][]string{"k8s-version": {"1.13"}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "excluding unknown parameters is rejected",
+			template: JobTemplate{
+				Matrix:  map[string][]string{"version": {"1.13"}},
+				Exclude: []map[string]string{{"provider": "aws"}},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		jc := &JobConfig{JobTemplates: []JobTemplate{tc.template}}
+		err := jc.expandJobTemplates()
+		if tc.expectedErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if jc.JobTemplates != nil {
+			t.Errorf("%s: expected templates to be removed after expansion", tc.name)
+		}
+		presubmits := map[string][]string{}
+		for repo, jobs := range jc.Presubmits {
+			for _, job := range jobs {
+				presubmits[repo] = append(presubmits[repo], job.Name)
+				if job.MatrixValues == nil {
+					t.Errorf("%s: expected matrix values on generated job %s", tc.name, job.Name)
+				}
+			}
+		}
+		if tc.presubmits == nil {
+			tc.presubmits = map[string][]string{}
+		}
+		if !reflect.DeepEqual(presubmits, tc.presubmits) {
+			t.Errorf("%s: expected presubmits %v, got %v", tc.name, tc.presubmits, presubmits)
+		}
+		var periodics []string
+		for _, job := range jc.Periodics {
+			periodics = append(periodics, job.Name)
+		}
+		if !reflect.DeepEqual(periodics, tc.periodics) {
+			t.Errorf("%s: expected periodics %v, got %v", tc.name, tc.periodics, periodics)
+		}
+	}
+}
+
+func TestLoadExpandsJobTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jobtemplates")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"config.yaml": "plank: {}\n",
+		"jobs/e2e.yaml": `job_templates:
+- matrix:
+    version: ["1.13", "1.14"]
+  periodics:
+  - name: ci-e2e-{{.version}}
+    interval: 1h
+    spec:
+      containers:
+      - image: "e2e:{{.version}}"
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	c, err := Load(filepath.Join(dir, "config.yaml"), filepath.Join(dir, "jobs"))
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	if len(c.Periodics) != 2 {
+		t.Fatalf("expected two generated periodics, got %d", len(c.Periodics))
+	}
+	for i, version := range []string{"1.13", "1.14"} {
+		job := c.Periodics[i]
+		if job.Name != "ci-e2e-"+version || job.Spec.Containers[0].Image != "e2e:"+version {
+			t.Errorf("expected job generated for %s, got %s running %s", version, job.Name, job.Spec.Containers[0].Image)
+		}
+		if job.SourcePath != filepath.Join(dir, "jobs", "e2e.yaml") {
+			t.Errorf("expected generated job to record its source path, got %q", job.SourcePath)
+		}
+	}
+}
@@ -25,6 +25,10 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// remoteRefreshInterval is how often secrets stored in a secret manager are
+// read again to pick up rotated values.
+var remoteRefreshInterval = 5 * time.Minute
+
 // Agent watches a path and automatically loads the secrets stored.
 type Agent struct {
 	sync.RWMutex
@@ -42,6 +46,10 @@ func (a *Agent) Start(paths []string) error {
 
 	// Start one goroutine for each file to monitor and update the secret's values.
 	for secretPath := range secretsMap {
+		if IsReference(secretPath) {
+			go a.refreshSecret(secretPath)
+			continue
+		}
 		go a.reloadSecret(secretPath)
 	}
 
@@ -83,6 +91,19 @@ func (a *Agent) reloadSecret(secretPath string) {
 	}
 }
 
+// refreshSecret periodically reads a secret stored in a secret manager. The
+// last value that was read is kept while the secret manager cannot be read.
+func (a *Agent) refreshSecret(reference string) {
+	logger := logrus.WithField("secret-reference", reference)
+	for range time.Tick(remoteRefreshInterval) {
+		if secretValue, err := LoadSingleSecret(reference); err != nil {
+			logger.WithError(err).Error("Error refreshing secret.")
+		} else {
+			a.setSecret(reference, secretValue)
+		}
+	}
+}
+
 // GetSecret returns the value of a secret stored in a map.
 func (a *Agent) GetSecret(secretPath string) []byte {
 	a.RLock()
@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2/google"
+)
+
+// Provider reads secrets from an external secret manager. Secrets stored in
+// a secret manager are referenced as <scheme>://<path> wherever a path to a
+// secret file is accepted.
+type Provider interface {
+	// GetSecret returns the current value of the secret at the path,
+	// which is the reference without the scheme.
+	GetSecret(path string) ([]byte, error)
+}
+
+var (
+	providersLock sync.RWMutex
+	providers     = map[string]Provider{
+		"vault": &vaultProvider{},
+		"gsm":   &gsmProvider{},
+	}
+)
+
+// RegisterProvider makes secrets referenced with the scheme be read from
+// the provider, replacing any provider registered for the scheme before.
+func RegisterProvider(scheme string, provider Provider) {
+	providersLock.Lock()
+	defer providersLock.Unlock()
+	providers[scheme] = provider
+}
+
+// IsReference determines if the secret is stored in a secret manager
+// rather than in a file.
+func IsReference(path string) bool {
+	_, _, ok := providerFor(path)
+	return ok
+}
+
+func providerFor(reference string) (Provider, string, bool) {
+	parts := strings.SplitN(reference, "://", 2)
+	if len(parts) != 2 {
+		return nil, "", false
+	}
+	providersLock.RLock()
+	defer providersLock.RUnlock()
+	provider, ok := providers[parts[0]]
+	return provider, parts[1], ok
+}
+
+// vaultProvider reads secrets from the key/value secrets engine of Vault,
+// referenced as vault://<mount>/<path>#<key>. The server and credentials
+// are configured with the VAULT_ADDR and VAULT_TOKEN environment variables.
+type vaultProvider struct{}
+
+func (p *vaultProvider) GetSecret(path string) ([]byte, error) {
+	parts := strings.SplitN(path, "#", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("vault secret %q does not reference a key with #<key>", path)
+	}
+	address := os.Getenv("VAULT_ADDR")
+	if address == "" {
+		return nil, fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(address, "/")+"/v1/"+parts[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := getJSON(http.DefaultClient, req, &response); err != nil {
+		return nil, fmt.Errorf("could not read vault secret %q: %v", parts[0], err)
+	}
+
+	data := response.Data
+	// version 2 of the secrets engine nests the secret in another data field
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, found := data[parts[1]]; !found {
+			data = nested
+		}
+	}
+	value, ok := data[parts[1]].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault secret %q has no string value for key %q", parts[0], parts[1])
+	}
+	return []byte(strings.TrimSpace(value)), nil
+}
+
+// gsmProvider reads secrets from Google Secret Manager, referenced as
+// gsm://projects/<project>/secrets/<secret>[/versions/<version>]. The
+// latest version is read unless one is given. Application default
+// credentials are used to authenticate.
+type gsmProvider struct {
+	// endpoint and client can be replaced in tests
+	endpoint string
+	client   func() (*http.Client, error)
+}
+
+func (p *gsmProvider) GetSecret(path string) ([]byte, error) {
+	if !strings.Contains(path, "/versions/") {
+		path = path + "/versions/latest"
+	}
+	endpoint, getClient := p.endpoint, p.client
+	if endpoint == "" {
+		endpoint = "https://secretmanager.googleapis.com"
+	}
+	if getClient == nil {
+		getClient = func() (*http.Client, error) {
+			return google.DefaultClient(context.Background(), "https://www.googleapis.com/auth/cloud-platform")
+		}
+	}
+	client, err := getClient()
+	if err != nil {
+		return nil, fmt.Errorf("could not create client for Google Secret Manager: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint+"/v1/"+path+":access", nil)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := getJSON(client, req, &response); err != nil {
+		return nil, fmt.Errorf("could not access secret %q: %v", path, err)
+	}
+	value, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode secret %q: %v", path, err)
+	}
+	return []byte(strings.TrimSpace(string(value))), nil
+}
+
+func getJSON(client *http.Client, req *http.Request, into interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got status code %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(into)
+}
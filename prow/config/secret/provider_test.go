@@ -0,0 +1,154 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/prow":
+			w.Write([]byte(`{"data": {"token": "v1-secret\n"}}`))
+		case "/v1/secret/data/prow":
+			w.Write([]byte(`{"data": {"data": {"token": "v2-secret"}, "metadata": {"version": 3}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer os.Setenv("VAULT_ADDR", os.Getenv("VAULT_ADDR"))
+	defer os.Setenv("VAULT_TOKEN", os.Getenv("VAULT_TOKEN"))
+	os.Setenv("VAULT_ADDR", server.URL)
+	os.Setenv("VAULT_TOKEN", "root")
+
+	testCases := []struct {
+		reference   string
+		expected    string
+		expectedErr bool
+	}{
+		{reference: "vault://kv/prow#token", expected: "v1-secret"},
+		{reference: "vault://secret/data/prow#token", expected: "v2-secret"},
+		{reference: "vault://secret/data/prow#missing", expectedErr: true},
+		{reference: "vault://secret/data/prow", expectedErr: true},
+		{reference: "vault://secret/data/other#token", expectedErr: true},
+	}
+	for _, tc := range testCases {
+		value, err := LoadSingleSecret(tc.reference)
+		if tc.expectedErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %q", tc.reference, value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.reference, err)
+		} else if string(value) != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.reference, tc.expected, value)
+		}
+	}
+}
+
+func TestGSMProvider(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		// "c2VjcmV0Cg==" is "secret\n"
+		w.Write([]byte(`{"name": "x", "payload": {"data": "c2VjcmV0Cg=="}}`))
+	}))
+	defer server.Close()
+	provider := &gsmProvider{endpoint: server.URL, client: func() (*http.Client, error) { return server.Client(), nil }}
+
+	for _, path := range []string{"projects/p/secrets/s", "projects/p/secrets/s/versions/2"} {
+		value, err := provider.GetSecret(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(value) != "secret" {
+			t.Errorf("expected secret value, got %q", value)
+		}
+	}
+	expected := []string{"/v1/projects/p/secrets/s/versions/latest:access", "/v1/projects/p/secrets/s/versions/2:access"}
+	for i := range expected {
+		if i >= len(requested) || requested[i] != expected[i] {
+			t.Errorf("expected requests %v, got %v", expected, requested)
+			break
+		}
+	}
+}
+
+type fakeProvider struct {
+	sync.Mutex
+	value string
+	err   error
+}
+
+func (p *fakeProvider) GetSecret(path string) ([]byte, error) {
+	p.Lock()
+	defer p.Unlock()
+	return []byte(p.value), p.err
+}
+
+func (p *fakeProvider) set(value string, err error) {
+	p.Lock()
+	defer p.Unlock()
+	p.value, p.err = value, err
+}
+
+func TestAgentRefreshesReferences(t *testing.T) {
+	defer func(interval time.Duration) { remoteRefreshInterval = interval }(remoteRefreshInterval)
+	remoteRefreshInterval = 10 * time.Millisecond
+	provider := &fakeProvider{value: "first"}
+	RegisterProvider("fake", provider)
+
+	agent := &Agent{}
+	if err := agent.Start([]string{"fake://token"}); err != nil {
+		t.Fatalf("unexpected error starting agent: %v", err)
+	}
+	getToken := agent.GetTokenGenerator("fake://token")
+	if value := string(getToken()); value != "first" {
+		t.Fatalf("expected initial value, got %q", value)
+	}
+
+	waitFor := func(expected string) {
+		for i := 0; i < 100; i++ {
+			if string(getToken()) == expected {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("expected value %q, got %q", expected, getToken())
+	}
+	provider.set("rotated", nil)
+	waitFor("rotated")
+	provider.set("", errors.New("unavailable"))
+	time.Sleep(50 * time.Millisecond)
+	if value := string(getToken()); value != "rotated" {
+		t.Errorf("expected last value to be kept while the provider fails, got %q", value)
+	}
+}
@@ -36,8 +36,12 @@ func LoadSecrets(paths []string) (map[string][]byte, error) {
 	return secretsMap, nil
 }
 
-// LoadSingleSecret reads and returns the value of a single file.
+// LoadSingleSecret reads and returns the value of a single file, or of a
+// secret in a secret manager if the path references one.
 func LoadSingleSecret(path string) ([]byte, error) {
+	if provider, secretPath, ok := providerFor(path); ok {
+		return provider.GetSecret(secretPath)
+	}
 	b, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("error reading %s: %v", path, err)
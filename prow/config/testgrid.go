@@ -0,0 +1,99 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+// Annotations on jobs the testgrid configurator uses to generate the
+// testgrid configuration of jobs, so it does not have to be kept in sync
+// with the job configuration by hand.
+const (
+	// TestgridDashboardsAnnotation is a comma-separated list of dashboards
+	// the job is shown on
+	TestgridDashboardsAnnotation = "testgrid-dashboards"
+	// TestgridTabNameAnnotation is the name of the tab of the job on its
+	// dashboards, the job name by default
+	TestgridTabNameAnnotation = "testgrid-tab-name"
+	// TestgridAlertEmailAnnotation is a comma-separated list of addresses
+	// to alert about failures of the job
+	TestgridAlertEmailAnnotation = "testgrid-alert-email"
+	// TestgridNumFailuresToAlertAnnotation is the number of consecutive
+	// failures of the job before alerting
+	TestgridNumFailuresToAlertAnnotation = "testgrid-num-failures-to-alert"
+	// TestgridDescriptionAnnotation describes the job on its dashboard tabs
+	TestgridDescriptionAnnotation = "testgrid-description"
+)
+
+// TestgridDashboards returns the dashboards listed in the annotations
+func TestgridDashboards(annotations map[string]string) []string {
+	return splitList(annotations[TestgridDashboardsAnnotation])
+}
+
+// TestgridAlertEmails returns the alert addresses listed in the annotations
+func TestgridAlertEmails(annotations map[string]string) []string {
+	return splitList(annotations[TestgridAlertEmailAnnotation])
+}
+
+// TestgridNumFailuresToAlert returns the number of failures to alert after
+// set in the annotations, or 0 if unset
+func TestgridNumFailuresToAlert(annotations map[string]string) int {
+	n, _ := strconv.Atoi(annotations[TestgridNumFailuresToAlertAnnotation])
+	return n
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validateTestgridAnnotations ensures the testgrid annotations of a job can
+// be turned into testgrid configuration
+func validateTestgridAnnotations(annotations map[string]string) error {
+	_, onDashboards := annotations[TestgridDashboardsAnnotation]
+	if onDashboards && len(TestgridDashboards(annotations)) == 0 {
+		return fmt.Errorf("annotation %s must list at least one dashboard", TestgridDashboardsAnnotation)
+	}
+	for _, annotation := range []string{TestgridTabNameAnnotation, TestgridAlertEmailAnnotation, TestgridNumFailuresToAlertAnnotation, TestgridDescriptionAnnotation} {
+		if _, ok := annotations[annotation]; ok && !onDashboards {
+			return fmt.Errorf("annotation %s requires the %s annotation", annotation, TestgridDashboardsAnnotation)
+		}
+	}
+	if name, ok := annotations[TestgridTabNameAnnotation]; ok && strings.TrimSpace(name) == "" {
+		return fmt.Errorf("annotation %s must not be empty", TestgridTabNameAnnotation)
+	}
+	for _, address := range TestgridAlertEmails(annotations) {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("annotation %s has invalid address %q: %v", TestgridAlertEmailAnnotation, address, err)
+		}
+	}
+	if value, ok := annotations[TestgridNumFailuresToAlertAnnotation]; ok {
+		if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+			return fmt.Errorf("annotation %s must be a positive number, not %q", TestgridNumFailuresToAlertAnnotation, value)
+		}
+	}
+	return nil
+}
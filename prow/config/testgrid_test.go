@@ -0,0 +1,83 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateTestgridAnnotations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expectErr   bool
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "all annotations",
+			annotations: map[string]string{
+				TestgridDashboardsAnnotation:         "sig-testing-misc, sig-testing-prow",
+				TestgridTabNameAnnotation:            "unit",
+				TestgridAlertEmailAnnotation:         "a@example.com, b@example.com",
+				TestgridNumFailuresToAlertAnnotation: "3",
+				TestgridDescriptionAnnotation:        "runs unit tests",
+			},
+		},
+		{
+			name:        "no dashboards listed",
+			annotations: map[string]string{TestgridDashboardsAnnotation: " , "},
+			expectErr:   true,
+		},
+		{
+			name:        "alert without dashboards",
+			annotations: map[string]string{TestgridAlertEmailAnnotation: "a@example.com"},
+			expectErr:   true,
+		},
+		{
+			name:        "invalid address",
+			annotations: map[string]string{TestgridDashboardsAnnotation: "dash", TestgridAlertEmailAnnotation: "not an address"},
+			expectErr:   true,
+		},
+		{
+			name:        "empty tab name",
+			annotations: map[string]string{TestgridDashboardsAnnotation: "dash", TestgridTabNameAnnotation: ""},
+			expectErr:   true,
+		},
+		{
+			name:        "non-positive number of failures",
+			annotations: map[string]string{TestgridDashboardsAnnotation: "dash", TestgridNumFailuresToAlertAnnotation: "0"},
+			expectErr:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateTestgridAnnotations(tc.annotations); tc.expectErr != (err != nil) {
+				t.Errorf("expected error: %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestTestgridDashboards(t *testing.T) {
+	annotations := map[string]string{TestgridDashboardsAnnotation: "a, b,,c "}
+	if dashboards := TestgridDashboards(annotations); !reflect.DeepEqual(dashboards, []string{"a", "b", "c"}) {
+		t.Errorf("expected dashboards [a b c], got %v", dashboards)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	yamlv2 "gopkg.in/yaml.v2"
+
+	"k8s.io/test-infra/prow/errorutil"
+)
+
+// FieldError is a problem with the value at a position in a config file.
+// Line and Column are 1-indexed and zero if the position is unknown.
+type FieldError struct {
+	File   string
+	Line   int
+	Column int
+	// Field is the path to the field, like presubmits[org/repo][0].name
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	position := e.File
+	if e.Line > 0 {
+		position = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s", position, e.Field, e.Message)
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownField is a key in a document that the target type does not accept
+type unknownField struct {
+	field string
+	key   string
+	// occurrence is the index of this key among all keys with the
+	// same name in the document, in document order
+	occurrence int
+}
+
+// fieldWalker compares a generic YAML document to the type it is
+// decoded into, recording all keys the type does not accept
+type fieldWalker struct {
+	occurrences map[string]int
+	unknown     []unknownField
+}
+
+// jsonField finds the field of the struct type that the JSON decoder would
+// fill for the key, following the same rules as encoding/json
+func jsonField(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			if embedded, found := jsonField(fieldType, key); found {
+				return embedded, true
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// walk visits the node expecting it to decode into the type t. A nil type
+// means the node is not checked, but its keys are still counted.
+func (w *fieldWalker) walk(node interface{}, t reflect.Type, field string) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && (t.Kind() == reflect.Interface || reflect.PtrTo(t).Implements(jsonUnmarshalerType)) {
+		t = nil // decoded by custom logic we cannot check
+	}
+
+	switch n := node.(type) {
+	case yamlv2.MapSlice:
+		for _, item := range n {
+			key := fmt.Sprint(item.Key)
+			occurrence := w.occurrences[key]
+			w.occurrences[key]++
+
+			var childType reflect.Type
+			var childField string
+			switch {
+			case t == nil:
+			case t.Kind() == reflect.Struct:
+				var found bool
+				if childType, found = jsonField(t, key); !found {
+					w.unknown = append(w.unknown, unknownField{field: joinField(field, key), key: key, occurrence: occurrence})
+				}
+				childField = joinField(field, key)
+			case t.Kind() == reflect.Map:
+				childType = t.Elem()
+				childField = fmt.Sprintf("%s[%s]", field, key)
+			}
+			w.walk(item.Value, childType, childField)
+		}
+	case []interface{}:
+		var elemType reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elemType = t.Elem()
+		}
+		for i, item := range n {
+			w.walk(item, elemType, fmt.Sprintf("%s[%d]", field, i))
+		}
+	}
+}
+
+func joinField(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// keyPattern matches a key in block style YAML, optionally as the first
+// key of a sequence item, and captures the indentation before the key
+var keyPattern = regexp.MustCompile(`^([\s-]*)(?:"([^"]+)"|'([^']+)'|([^\s"'#:][^:#]*?))\s*:(?:\s|$)`)
+
+// blockScalarPattern matches a line that starts a literal or folded block,
+// either as the value of a key or as an item of a sequence
+var blockScalarPattern = regexp.MustCompile(`(?:^\s*-|:)\s*[|>][-+0-9]*\s*$`)
+
+type keyPosition struct {
+	line, column int
+}
+
+// keyPositions finds the positions of all keys in block style YAML in
+// document order, skipping the content of block scalars
+func keyPositions(data []byte) map[string][]keyPosition {
+	positions := map[string][]keyPosition{}
+	blockIndent := -1
+	for i, line := range strings.Split(string(data), "\n") {
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if blockIndent >= 0 {
+			if strings.TrimSpace(line) == "" || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+		match := keyPattern.FindStringSubmatch(line)
+		if match != nil {
+			key := match[2] + match[3] + match[4]
+			positions[key] = append(positions[key], keyPosition{line: i + 1, column: len(match[1]) + 1})
+		}
+		if blockScalarPattern.MatchString(line) {
+			// the block holds all following lines indented further
+			// than the key or sequence item that starts it
+			blockIndent = indent
+			if match != nil {
+				blockIndent = len(match[1])
+			}
+		}
+	}
+	return positions
+}
+
+// checkUnknownFields reports every key in the YAML document that would be
+// silently dropped when decoding it into the target, with its position
+func checkUnknownFields(file string, data []byte, target interface{}) error {
+	var document yamlv2.MapSlice
+	if err := yamlv2.UnmarshalStrict(data, &document); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	walker := &fieldWalker{occurrences: map[string]int{}}
+	walker.walk(document, reflect.TypeOf(target), "")
+	if len(walker.unknown) == 0 {
+		return nil
+	}
+
+	positions := keyPositions(data)
+	var errs []error
+	for _, unknown := range walker.unknown {
+		fieldErr := &FieldError{File: file, Field: unknown.field, Message: fmt.Sprintf("unknown field %q", unknown.key)}
+		// positions can only be found reliably if the scan of the
+		// text found each occurrence the parser did
+		if found := positions[unknown.key]; len(found) == walker.occurrences[unknown.key] {
+			fieldErr.Line = found[unknown.occurrence].line
+			fieldErr.Column = found[unknown.occurrence].column
+		}
+		errs = append(errs, fieldErr)
+	}
+	return errorutil.NewAggregate(errs...)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/test-infra/prow/errorutil"
+)
+
+func TestCheckUnknownFields(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   string
+		expected []string
+	}{
+		{
+			name: "valid config",
+			config: `presubmits:
+  org/repo:
+  - name: job
+    max_concurrency: 1
+    spec:
+      containers:
+      - image: alpine
+        resources:
+          requests:
+            cpu: 1
+`,
+		},
+		{
+			name: "typos are reported with their position",
+			config: `presubmits:
+  org/repo:
+  - name: job
+    max_concurency: 1
+  - name: other
+    spec:
+      containers:
+      - image: alpine
+        comand: ["true"]
+periodics:
+- name: periodic
+  intreval: 1h
+`,
+			expected: []string{
+				`file.yaml:4:5: presubmits[org/repo][0].max_concurency: unknown field "max_concurency"`,
+				`file.yaml:9:9: presubmits[org/repo][1].spec.containers[0].comand: unknown field "comand"`,
+				`file.yaml:12:3: periodics[0].intreval: unknown field "intreval"`,
+			},
+		},
+		{
+			name: "repeated keys are told apart",
+			config: `presubmits:
+  org/repo:
+  - name: job
+    agnet: kubernetes
+  - name: other
+    agnet: kubernetes
+`,
+			expected: []string{
+				`file.yaml:4:5: presubmits[org/repo][0].agnet: unknown field "agnet"`,
+				`file.yaml:6:5: presubmits[org/repo][1].agnet: unknown field "agnet"`,
+			},
+		},
+		{
+			name: "block scalars do not confuse positions",
+			config: `presubmits:
+  org/repo:
+  - name: job
+    spec:
+      containers:
+      - args:
+        - |
+          optional: true
+        optional: true
+`,
+			expected: []string{
+				`file.yaml:9:9: presubmits[org/repo][0].spec.containers[0].optional: unknown field "optional"`,
+			},
+		},
+		{
+			name:     "positions are omitted when they cannot be found",
+			config:   `presubmits: {org/repo: [{name: job, agnet: kubernetes}]}`,
+			expected: []string{`file.yaml: presubmits[org/repo][0].agnet: unknown field "agnet"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		err := checkUnknownFields("file.yaml", []byte(tc.config), &JobConfig{})
+		var actual []string
+		if err != nil {
+			aggregate, ok := err.(errorutil.Aggregate)
+			if !ok {
+				t.Errorf("%s: expected an aggregate error, got %v", tc.name, err)
+				continue
+			}
+			actual = aggregate.Strings()
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: expected errors\n%s\ngot\n%s", tc.name, strings.Join(tc.expected, "\n"), strings.Join(actual, "\n"))
+		}
+	}
+}
+
+func TestLoadConfigReportsAllUnknownFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unknownfields")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"config.yaml":      "plank: {}\n",
+		"jobs/first.yaml":  "periodics:\n- name: first\n  intreval: 1h\n",
+		"jobs/second.yaml": "periodics:\n- name: second\n  interval: 1h\n  lables: {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	_, err = Load(filepath.Join(dir, "config.yaml"), filepath.Join(dir, "jobs"))
+	if err == nil {
+		t.Fatal("expected an error loading config with unknown fields")
+	}
+	for _, field := range []string{`"intreval"`, `"lables"`} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected error to report unknown field %s, got %v", field, err)
+		}
+	}
+}
@@ -18,6 +18,7 @@ limitations under the License.
 package crier
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -25,6 +26,7 @@ import (
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -36,6 +38,8 @@ import (
 	"k8s.io/test-infra/prow/apis/prowjobs/v1"
 	clientset "k8s.io/test-infra/prow/client/clientset/versioned"
 	pjinformers "k8s.io/test-infra/prow/client/informers/externalversions/prowjobs/v1"
+	"k8s.io/test-infra/prow/pjutil"
+	"k8s.io/test-infra/prow/tracing"
 )
 
 type reportClient interface {
@@ -227,6 +231,10 @@ func (c *Controller) processNextItem() bool {
 		return true
 	}
 
+	// log with the fields of the job, including the GUID of the event
+	// that triggered it, so reports can be correlated with the event
+	log := logrus.WithField("prowjob", keyRaw).WithField("reporter", c.reporter.GetName()).WithFields(pjutil.ProwJobFields(pj))
+
 	// we set omitempty on PrevReportStates, so here we need to init it if is nil
 	if pj.Status.PrevReportStates == nil {
 		pj.Status.PrevReportStates = map[string]v1.ProwJobState{}
@@ -234,43 +242,59 @@ func (c *Controller) processNextItem() bool {
 
 	// already reported current state
 	if pj.Status.PrevReportStates[c.reporter.GetName()] == pj.Status.State {
-		logrus.WithField("prowjob", keyRaw).Info("Already reported")
+		log.Info("Already reported")
 		c.queue.Forget(key)
 		return true
 	}
 
-	logrus.WithField("prowjob", keyRaw).Infof("Will report state : %s", pj.Status.State)
+	log.Infof("Will report state : %s", pj.Status.State)
 
-	if err := c.reporter.Report(pj); err != nil {
-		logrus.WithError(err).WithField("prowjob", keyRaw).Error("failed to report job")
+	if err := c.report(pj); err != nil {
+		log.WithError(err).Error("failed to report job")
 		return c.retry(key, err)
 	}
 
-	logrus.WithField("prowjob", keyRaw).Info("Updated job, now will update pj")
+	log.Info("Updated job, now will update pj")
 
 	if err := c.updateReportState(pj); err != nil {
-		logrus.WithError(err).WithField("prowjob", keyRaw).Error("failed to update report state")
+		log.WithError(err).Error("failed to update report state")
 
 		// theoretically patch should not have this issue, but in case:
 		// it might be out-dated, try to re-fetch pj and try again
 
 		updatedPJ, err := c.pjclientset.Prow().ProwJobs(pj.Namespace).Get(pj.Name, metav1.GetOptions{})
 		if err != nil {
-			logrus.WithError(err).WithField("prowjob", keyRaw).Error("failed to get prowjob from apiserver")
+			log.WithError(err).Error("failed to get prowjob from apiserver")
 			c.queue.Forget(key)
 			return true
 		}
 
 		if err := c.updateReportState(updatedPJ); err != nil {
 			// shrug
-			logrus.WithError(err).WithField("prowjob", keyRaw).Error("failed to update report state again, give up")
+			log.WithError(err).Error("failed to update report state again, give up")
 			c.queue.Forget(key)
 			return true
 		}
 	}
 
-	logrus.WithField("prowjob", keyRaw).Infof("Hunky Dory!, pj : %v, state : %s", pj.Spec.Job, pj.Status.State)
+	log.Infof("Hunky Dory!, pj : %v, state : %s", pj.Spec.Job, pj.Status.State)
 
 	c.queue.Forget(key)
 	return true
 }
+
+// report reports the job, continuing the trace of the job if it is traced
+func (c *Controller) report(pj *v1.ProwJob) error {
+	parent, traced := tracing.FromAnnotations(pj.Annotations)
+	if !traced {
+		return c.reporter.Report(pj)
+	}
+	_, span := trace.StartSpanWithRemoteParent(context.Background(), "report "+c.reporter.GetName(), parent)
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("state", string(pj.Status.State)))
+	err := c.reporter.Report(pj)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+	return err
+}
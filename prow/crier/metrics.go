@@ -0,0 +1,50 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crier
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/test-infra/prow/apis/prowjobs/v1"
+	pjinformers "k8s.io/test-infra/prow/client/informers/externalversions/prowjobs/v1"
+	"k8s.io/test-infra/prow/kube"
+)
+
+// RecordMetrics records the run duration of every ProwJob that completes,
+// regardless of the agent that ran it, and exports the number of ProwJobs
+// in each state.
+func RecordMetrics(informer pjinformers.ProwJobInformer) {
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldJob, oldOK := oldObj.(*v1.ProwJob)
+			newJob, newOK := newObj.(*v1.ProwJob)
+			if oldOK && newOK && completed(oldJob, newJob) {
+				kube.ObserveProwJobCompleted(*newJob)
+			}
+		},
+	})
+	prometheus.MustRegister(kube.NewProwJobStateCollector(func() ([]*v1.ProwJob, error) {
+		return informer.Lister().List(labels.Everything())
+	}))
+}
+
+// completed determines if the update completed the job
+func completed(oldJob, newJob *v1.ProwJob) bool {
+	return !oldJob.Complete() && newJob.Complete()
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package hook
 
 import (
+	"context"
+
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/test-infra/prow/github"
@@ -49,7 +51,7 @@ var (
 	}
 )
 
-func (s *Server) handleReviewEvent(l *logrus.Entry, re github.ReviewEvent) {
+func (s *Server) handleReviewEvent(ctx context.Context, l *logrus.Entry, re github.ReviewEvent) {
 	defer s.wg.Done()
 	l = l.WithFields(logrus.Fields{
 		github.OrgLogField:  re.Repo.Owner.Login,
@@ -64,7 +66,8 @@ func (s *Server) handleReviewEvent(l *logrus.Entry, re github.ReviewEvent) {
 		s.wg.Add(1)
 		go func(p string, h plugins.ReviewEventHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, l.WithField("plugin", p))
+			agent, span := s.startPlugin(ctx, l, p)
+			defer span.End()
 			agent.InitializeCommentPruner(
 				re.Repo.Owner.Login,
 				re.Repo.Name,
@@ -81,6 +84,7 @@ func (s *Server) handleReviewEvent(l *logrus.Entry, re github.ReviewEvent) {
 		return
 	}
 	s.handleGenericComment(
+		ctx,
 		l,
 		&github.GenericCommentEvent{
 			GUID:         re.GUID,
@@ -100,7 +104,7 @@ func (s *Server) handleReviewEvent(l *logrus.Entry, re github.ReviewEvent) {
 	)
 }
 
-func (s *Server) handleReviewCommentEvent(l *logrus.Entry, rce github.ReviewCommentEvent) {
+func (s *Server) handleReviewCommentEvent(ctx context.Context, l *logrus.Entry, rce github.ReviewCommentEvent) {
 	defer s.wg.Done()
 	l = l.WithFields(logrus.Fields{
 		github.OrgLogField:  rce.Repo.Owner.Login,
@@ -115,7 +119,8 @@ func (s *Server) handleReviewCommentEvent(l *logrus.Entry, rce github.ReviewComm
 		s.wg.Add(1)
 		go func(p string, h plugins.ReviewCommentEventHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, l.WithField("plugin", p))
+			agent, span := s.startPlugin(ctx, l, p)
+			defer span.End()
 			agent.InitializeCommentPruner(
 				rce.Repo.Owner.Login,
 				rce.Repo.Name,
@@ -132,6 +137,7 @@ func (s *Server) handleReviewCommentEvent(l *logrus.Entry, rce github.ReviewComm
 		return
 	}
 	s.handleGenericComment(
+		ctx,
 		l,
 		&github.GenericCommentEvent{
 			GUID:         rce.GUID,
@@ -151,7 +157,7 @@ func (s *Server) handleReviewCommentEvent(l *logrus.Entry, rce github.ReviewComm
 	)
 }
 
-func (s *Server) handlePullRequestEvent(l *logrus.Entry, pr github.PullRequestEvent) {
+func (s *Server) handlePullRequestEvent(ctx context.Context, l *logrus.Entry, pr github.PullRequestEvent) {
 	defer s.wg.Done()
 	l = l.WithFields(logrus.Fields{
 		github.OrgLogField:  pr.Repo.Owner.Login,
@@ -165,7 +171,8 @@ func (s *Server) handlePullRequestEvent(l *logrus.Entry, pr github.PullRequestEv
 		s.wg.Add(1)
 		go func(p string, h plugins.PullRequestHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, l.WithField("plugin", p))
+			agent, span := s.startPlugin(ctx, l, p)
+			defer span.End()
 			agent.InitializeCommentPruner(
 				pr.Repo.Owner.Login,
 				pr.Repo.Name,
@@ -184,6 +191,7 @@ func (s *Server) handlePullRequestEvent(l *logrus.Entry, pr github.PullRequestEv
 		return
 	}
 	s.handleGenericComment(
+		ctx,
 		l,
 		&github.GenericCommentEvent{
 			GUID:         pr.GUID,
@@ -203,7 +211,7 @@ func (s *Server) handlePullRequestEvent(l *logrus.Entry, pr github.PullRequestEv
 	)
 }
 
-func (s *Server) handlePushEvent(l *logrus.Entry, pe github.PushEvent) {
+func (s *Server) handlePushEvent(ctx context.Context, l *logrus.Entry, pe github.PushEvent) {
 	defer s.wg.Done()
 	l = l.WithFields(logrus.Fields{
 		github.OrgLogField:  pe.Repo.Owner.Name,
@@ -216,7 +224,8 @@ func (s *Server) handlePushEvent(l *logrus.Entry, pe github.PushEvent) {
 		s.wg.Add(1)
 		go func(p string, h plugins.PushEventHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, l.WithField("plugin", p))
+			agent, span := s.startPlugin(ctx, l, p)
+			defer span.End()
 			if err := h(agent, pe); err != nil {
 				agent.Logger.WithError(err).Error("Error handling PushEvent.")
 			}
@@ -224,7 +233,7 @@ func (s *Server) handlePushEvent(l *logrus.Entry, pe github.PushEvent) {
 	}
 }
 
-func (s *Server) handleIssueEvent(l *logrus.Entry, i github.IssueEvent) {
+func (s *Server) handleIssueEvent(ctx context.Context, l *logrus.Entry, i github.IssueEvent) {
 	defer s.wg.Done()
 	l = l.WithFields(logrus.Fields{
 		github.OrgLogField:  i.Repo.Owner.Login,
@@ -238,7 +247,8 @@ func (s *Server) handleIssueEvent(l *logrus.Entry, i github.IssueEvent) {
 		s.wg.Add(1)
 		go func(p string, h plugins.IssueHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, l.WithField("plugin", p))
+			agent, span := s.startPlugin(ctx, l, p)
+			defer span.End()
 			agent.InitializeCommentPruner(
 				i.Repo.Owner.Login,
 				i.Repo.Name,
@@ -257,6 +267,7 @@ func (s *Server) handleIssueEvent(l *logrus.Entry, i github.IssueEvent) {
 		return
 	}
 	s.handleGenericComment(
+		ctx,
 		l,
 		&github.GenericCommentEvent{
 			GUID:         i.GUID,
@@ -276,7 +287,7 @@ func (s *Server) handleIssueEvent(l *logrus.Entry, i github.IssueEvent) {
 	)
 }
 
-func (s *Server) handleIssueCommentEvent(l *logrus.Entry, ic github.IssueCommentEvent) {
+func (s *Server) handleIssueCommentEvent(ctx context.Context, l *logrus.Entry, ic github.IssueCommentEvent) {
 	defer s.wg.Done()
 	l = l.WithFields(logrus.Fields{
 		github.OrgLogField:  ic.Repo.Owner.Login,
@@ -290,7 +301,8 @@ func (s *Server) handleIssueCommentEvent(l *logrus.Entry, ic github.IssueComment
 		s.wg.Add(1)
 		go func(p string, h plugins.IssueCommentHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, l.WithField("plugin", p))
+			agent, span := s.startPlugin(ctx, l, p)
+			defer span.End()
 			agent.InitializeCommentPruner(
 				ic.Repo.Owner.Login,
 				ic.Repo.Name,
@@ -307,6 +319,7 @@ func (s *Server) handleIssueCommentEvent(l *logrus.Entry, ic github.IssueComment
 		return
 	}
 	s.handleGenericComment(
+		ctx,
 		l,
 		&github.GenericCommentEvent{
 			GUID:         ic.GUID,
@@ -326,7 +339,7 @@ func (s *Server) handleIssueCommentEvent(l *logrus.Entry, ic github.IssueComment
 	)
 }
 
-func (s *Server) handleStatusEvent(l *logrus.Entry, se github.StatusEvent) {
+func (s *Server) handleStatusEvent(ctx context.Context, l *logrus.Entry, se github.StatusEvent) {
 	defer s.wg.Done()
 	l = l.WithFields(logrus.Fields{
 		github.OrgLogField:  se.Repo.Owner.Login,
@@ -341,7 +354,8 @@ func (s *Server) handleStatusEvent(l *logrus.Entry, se github.StatusEvent) {
 		s.wg.Add(1)
 		go func(p string, h plugins.StatusEventHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, l.WithField("plugin", p))
+			agent, span := s.startPlugin(ctx, l, p)
+			defer span.End()
 			if err := h(agent, se); err != nil {
 				agent.Logger.WithError(err).Error("Error handling StatusEvent.")
 			}
@@ -364,12 +378,13 @@ func genericCommentAction(action string) github.GenericCommentEventAction {
 	return ""
 }
 
-func (s *Server) handleGenericComment(l *logrus.Entry, ce *github.GenericCommentEvent) {
+func (s *Server) handleGenericComment(ctx context.Context, l *logrus.Entry, ce *github.GenericCommentEvent) {
 	for p, h := range s.Plugins.GenericCommentHandlers(ce.Repo.Owner.Login, ce.Repo.Name) {
 		s.wg.Add(1)
 		go func(p string, h plugins.GenericCommentHandler) {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, l.WithField("plugin", p))
+			agent, span := s.startPlugin(ctx, l, p)
+			defer span.End()
 			agent.InitializeCommentPruner(
 				ce.Repo.Owner.Login,
 				ce.Repo.Name,
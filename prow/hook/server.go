@@ -18,6 +18,7 @@ package hook
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -28,10 +29,12 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 
 	"k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/github"
 	"k8s.io/test-infra/prow/plugins"
+	"k8s.io/test-infra/prow/tracing"
 )
 
 // Server implements http.Handler. It validates incoming GitHub webhooks and
@@ -78,6 +81,11 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 			github.EventGUID: eventGUID,
 		},
 	)
+	// the trace of the event continues in the spans of the plugins
+	// handling it and in the ProwJobs they create
+	ctx, span := trace.StartSpan(context.Background(), "webhook", trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("event-type", eventType), trace.StringAttribute(github.EventGUID, eventGUID))
 	// We don't want to fail the webhook due to a metrics error.
 	if counter, err := s.Metrics.WebhookCounter.GetMetricWithLabelValues(eventType); err != nil {
 		l.WithError(err).Warn("Failed to get metric for eventType " + eventType)
@@ -94,7 +102,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		i.GUID = eventGUID
 		srcRepo = i.Repo.FullName
 		s.wg.Add(1)
-		go s.handleIssueEvent(l, i)
+		go s.handleIssueEvent(ctx, l, i)
 	case "issue_comment":
 		var ic github.IssueCommentEvent
 		if err := json.Unmarshal(payload, &ic); err != nil {
@@ -103,7 +111,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		ic.GUID = eventGUID
 		srcRepo = ic.Repo.FullName
 		s.wg.Add(1)
-		go s.handleIssueCommentEvent(l, ic)
+		go s.handleIssueCommentEvent(ctx, l, ic)
 	case "pull_request":
 		var pr github.PullRequestEvent
 		if err := json.Unmarshal(payload, &pr); err != nil {
@@ -112,7 +120,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		pr.GUID = eventGUID
 		srcRepo = pr.Repo.FullName
 		s.wg.Add(1)
-		go s.handlePullRequestEvent(l, pr)
+		go s.handlePullRequestEvent(ctx, l, pr)
 	case "pull_request_review":
 		var re github.ReviewEvent
 		if err := json.Unmarshal(payload, &re); err != nil {
@@ -121,7 +129,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		re.GUID = eventGUID
 		srcRepo = re.Repo.FullName
 		s.wg.Add(1)
-		go s.handleReviewEvent(l, re)
+		go s.handleReviewEvent(ctx, l, re)
 	case "pull_request_review_comment":
 		var rce github.ReviewCommentEvent
 		if err := json.Unmarshal(payload, &rce); err != nil {
@@ -130,7 +138,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		rce.GUID = eventGUID
 		srcRepo = rce.Repo.FullName
 		s.wg.Add(1)
-		go s.handleReviewCommentEvent(l, rce)
+		go s.handleReviewCommentEvent(ctx, l, rce)
 	case "push":
 		var pe github.PushEvent
 		if err := json.Unmarshal(payload, &pe); err != nil {
@@ -139,7 +147,7 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		pe.GUID = eventGUID
 		srcRepo = pe.Repo.FullName
 		s.wg.Add(1)
-		go s.handlePushEvent(l, pe)
+		go s.handlePushEvent(ctx, l, pe)
 	case "status":
 		var se github.StatusEvent
 		if err := json.Unmarshal(payload, &se); err != nil {
@@ -148,12 +156,15 @@ func (s *Server) demuxEvent(eventType, eventGUID string, payload []byte, h http.
 		se.GUID = eventGUID
 		srcRepo = se.Repo.FullName
 		s.wg.Add(1)
-		go s.handleStatusEvent(l, se)
+		go s.handleStatusEvent(ctx, l, se)
 	default:
 		l.Debug("Ignoring unhandled event type. (Might still be handled by external plugins.)")
 	}
 	// Demux events only to external plugins that require this event.
 	if external := s.needDemux(eventType, srcRepo); len(external) > 0 {
+		if sc := span.SpanContext(); sc.IsSampled() {
+			h.Set("traceparent", tracing.Encode(sc))
+		}
 		go s.demuxExternal(l, external, payload, h)
 	}
 	return nil
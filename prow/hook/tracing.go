@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	prowv1 "k8s.io/test-infra/prow/client/clientset/versioned/typed/prowjobs/v1"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins"
+	"k8s.io/test-infra/prow/tracing"
+)
+
+// startPlugin creates the agent for a plugin handling an event and starts
+// the span tracing the handling. ProwJobs created by the plugin continue
+// the trace and are labeled with the GUID of the event, so that the logs
+// of all components handling them can be correlated.
+func (s *Server) startPlugin(ctx context.Context, l *logrus.Entry, plugin string) (plugins.Agent, *trace.Span) {
+	_, span := trace.StartSpan(ctx, "plugin "+plugin)
+	span.AddAttributes(trace.StringAttribute("plugin", plugin))
+	agent := plugins.NewAgent(s.ConfigAgent, s.Plugins, s.ClientAgent, l.WithField("plugin", plugin))
+	if agent.ProwJobClient != nil {
+		eventGUID, _ := l.Data[github.EventGUID].(string)
+		agent.ProwJobClient = &eventProwJobClient{ProwJobInterface: agent.ProwJobClient, eventGUID: eventGUID, spanContext: span.SpanContext()}
+	}
+	return agent, span
+}
+
+// eventProwJobClient records the event GUID and the trace context on all
+// ProwJobs it creates
+type eventProwJobClient struct {
+	prowv1.ProwJobInterface
+	eventGUID   string
+	spanContext trace.SpanContext
+}
+
+func (c *eventProwJobClient) Create(pj *prowapi.ProwJob) (*prowapi.ProwJob, error) {
+	if c.eventGUID != "" && pj.Labels[github.EventGUID] == "" {
+		if pj.Labels == nil {
+			pj.Labels = map[string]string{}
+		}
+		pj.Labels[github.EventGUID] = c.eventGUID
+	}
+	pj.Annotations = tracing.SetAnnotation(pj.Annotations, c.spanContext)
+	return c.ProwJobInterface.Create(pj)
+}
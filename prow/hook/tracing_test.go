@@ -0,0 +1,57 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hook
+
+import (
+	"testing"
+
+	"go.opencensus.io/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	"k8s.io/test-infra/prow/client/clientset/versioned/fake"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/tracing"
+)
+
+func TestEventProwJobClient(t *testing.T) {
+	sc := trace.SpanContext{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}, TraceOptions: trace.TraceOptions(1)}
+	client := &eventProwJobClient{
+		ProwJobInterface: fake.NewSimpleClientset().ProwV1().ProwJobs("prowjobs"),
+		eventGUID:        "guid",
+		spanContext:      sc,
+	}
+	created, err := client.Create(&prowapi.ProwJob{ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "prowjobs"}})
+	if err != nil {
+		t.Fatalf("unexpected error creating ProwJob: %v", err)
+	}
+	if actual, ok := tracing.FromAnnotations(created.Annotations); !ok || actual != sc {
+		t.Errorf("expected ProwJob to continue the trace, got annotations %v", created.Annotations)
+	}
+	if actual := created.Labels[github.EventGUID]; actual != "guid" {
+		t.Errorf("expected ProwJob to be labeled with the event GUID, got labels %v", created.Labels)
+	}
+
+	// the GUID set by the plugin is kept
+	created, err = client.Create(&prowapi.ProwJob{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "prowjobs", Labels: map[string]string{github.EventGUID: "own"}}})
+	if err != nil {
+		t.Fatalf("unexpected error creating ProwJob: %v", err)
+	}
+	if actual := created.Labels[github.EventGUID]; actual != "own" {
+		t.Errorf("expected the plugin's event GUID to be kept, got labels %v", created.Labels)
+	}
+}
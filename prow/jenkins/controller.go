@@ -417,13 +417,14 @@ func (c *Controller) syncTriggeredJob(pj prowapi.ProwJob, reports chan<- prowapi
 			pj.Status.URL = c.cfg().StatusErrorLink
 			pj.Status.Description = "Error starting Jenkins job."
 		} else {
-			pj.Status.State = prowapi.PendingState
+			pj.SetPending()
 			pj.Status.Description = "Jenkins job enqueued."
+			kube.ObserveProwJobScheduled(pj)
 		}
 	} else {
 		// If a Jenkins build already exists for this job, advance the ProwJob to Pending and
 		// it should be handled by syncPendingJob in the next sync.
-		pj.Status.State = prowapi.PendingState
+		pj.SetPending()
 		pj.Status.Description = "Jenkins job enqueued."
 	}
 	// Report to GitHub.
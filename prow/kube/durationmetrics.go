@@ -0,0 +1,150 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	coreapi "k8s.io/api/core/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+)
+
+// jobLabels identify the repository, type and cluster of a job
+var jobLabels = []string{"org", "repo", "type", "cluster"}
+
+var (
+	timeToSchedule = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "prowjob_time_to_schedule_seconds",
+		Help:    "Time from the creation of a ProwJob until it started executing.",
+		Buckets: []float64{5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200, 14400},
+	}, jobLabels)
+	timeInPending = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "prowjob_pod_pending_seconds",
+		Help:    "Time from the creation of the pod of a ProwJob until its first container started running.",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	}, jobLabels)
+	runDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "prowjob_run_duration_seconds",
+		Help:    "Time from the start of the execution of a ProwJob until it completed.",
+		Buckets: []float64{60, 300, 600, 1200, 1800, 3600, 7200, 10800, 14400, 21600, 43200, 86400},
+	}, append(jobLabels, "state"))
+)
+
+func init() {
+	prometheus.MustRegister(timeToSchedule)
+	prometheus.MustRegister(timeInPending)
+	prometheus.MustRegister(runDuration)
+}
+
+// jobLabelValues returns the values for jobLabels. Periodics are attributed
+// to the repository of their first extra ref, if they have any.
+func jobLabelValues(pj prowapi.ProwJob) []string {
+	var org, repo string
+	if pj.Spec.Refs != nil {
+		org, repo = pj.Spec.Refs.Org, pj.Spec.Refs.Repo
+	} else if len(pj.Spec.ExtraRefs) > 0 {
+		org, repo = pj.Spec.ExtraRefs[0].Org, pj.Spec.ExtraRefs[0].Repo
+	}
+	return []string{org, repo, string(pj.Spec.Type), pj.ClusterAlias()}
+}
+
+// ObserveProwJobScheduled records how long the job waited to start
+// executing. It should be called once the job is pending.
+func ObserveProwJobScheduled(pj prowapi.ProwJob) {
+	if pj.Status.PendingTime == nil {
+		return
+	}
+	timeToSchedule.WithLabelValues(jobLabelValues(pj)...).Observe(pj.Status.PendingTime.Sub(pj.Status.StartTime.Time).Seconds())
+}
+
+// ObservePodPending records how long the pod of the job waited for its
+// containers to start. It should be called once the pod completed.
+func ObservePodPending(pj prowapi.ProwJob, pod coreapi.Pod) {
+	var started time.Time
+	for _, status := range pod.Status.ContainerStatuses {
+		var containerStarted time.Time
+		if status.State.Running != nil {
+			containerStarted = status.State.Running.StartedAt.Time
+		} else if status.State.Terminated != nil {
+			containerStarted = status.State.Terminated.StartedAt.Time
+		}
+		if !containerStarted.IsZero() && (started.IsZero() || containerStarted.Before(started)) {
+			started = containerStarted
+		}
+	}
+	if started.IsZero() || pod.CreationTimestamp.IsZero() {
+		return
+	}
+	timeInPending.WithLabelValues(jobLabelValues(pj)...).Observe(started.Sub(pod.CreationTimestamp.Time).Seconds())
+}
+
+// ObserveProwJobCompleted records how long the job executed for. It should
+// be called once the job completed.
+func ObserveProwJobCompleted(pj prowapi.ProwJob) {
+	if pj.Status.CompletionTime == nil {
+		return
+	}
+	// jobs that were never pending did not execute
+	if pj.Status.PendingTime == nil {
+		return
+	}
+	labels := append(jobLabelValues(pj), string(pj.Status.State))
+	runDuration.WithLabelValues(labels...).Observe(pj.Status.CompletionTime.Sub(pj.Status.PendingTime.Time).Seconds())
+}
+
+var prowJobStateDesc = prometheus.NewDesc(
+	"prowjob_state",
+	"Number of ProwJobs in each state.",
+	append(jobLabels, "state"), nil,
+)
+
+// prowJobStateCollector exports the number of ProwJobs in
+// each state every time the metrics are scraped
+type prowJobStateCollector struct {
+	list func() ([]*prowapi.ProwJob, error)
+}
+
+// NewProwJobStateCollector returns a collector exporting the number of
+// ProwJobs in each state, by repository, type and cluster.
+func NewProwJobStateCollector(list func() ([]*prowapi.ProwJob, error)) prometheus.Collector {
+	return &prowJobStateCollector{list: list}
+}
+
+func (c *prowJobStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- prowJobStateDesc
+}
+
+func (c *prowJobStateCollector) Collect(ch chan<- prometheus.Metric) {
+	pjs, err := c.list()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(prowJobStateDesc, err)
+		return
+	}
+	type key struct {
+		org, repo, jobType, cluster, state string
+	}
+	counts := map[key]float64{}
+	for _, pj := range pjs {
+		labels := jobLabelValues(*pj)
+		counts[key{labels[0], labels[1], labels[2], labels[3], string(pj.Status.State)}]++
+	}
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(prowJobStateDesc, prometheus.GaugeValue, count, k.org, k.repo, k.jobType, k.cluster, k.state)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	coreapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+)
+
+func TestJobLabelValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     prowapi.ProwJobSpec
+		expected []string
+	}{
+		{
+			name:     "presubmit",
+			spec:     prowapi.ProwJobSpec{Type: prowapi.PresubmitJob, Cluster: "build", Refs: &prowapi.Refs{Org: "org", Repo: "repo"}},
+			expected: []string{"org", "repo", "presubmit", "build"},
+		},
+		{
+			name:     "periodic with extra refs",
+			spec:     prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, ExtraRefs: []prowapi.Refs{{Org: "org", Repo: "extra"}}},
+			expected: []string{"org", "extra", "periodic", prowapi.DefaultClusterAlias},
+		},
+		{
+			name:     "periodic without refs",
+			spec:     prowapi.ProwJobSpec{Type: prowapi.PeriodicJob},
+			expected: []string{"", "", "periodic", prowapi.DefaultClusterAlias},
+		},
+	}
+	for _, tc := range testCases {
+		if actual := jobLabelValues(prowapi.ProwJob{Spec: tc.spec}); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: expected labels %v, got %v", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func histogramSamples(t *testing.T, histogram *prometheus.HistogramVec, labels ...string) (uint64, float64) {
+	observer, err := histogram.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		t.Fatalf("failed to get histogram: %v", err)
+	}
+	var metric dto.Metric
+	if err := observer.(prometheus.Histogram).Write(&metric); err != nil {
+		t.Fatalf("failed to write histogram: %v", err)
+	}
+	return metric.Histogram.GetSampleCount(), metric.Histogram.GetSampleSum()
+}
+
+func TestObserveDurations(t *testing.T) {
+	start := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) *metav1.Time {
+		t := metav1.NewTime(start.Add(time.Duration(seconds) * time.Second))
+		return &t
+	}
+	pj := prowapi.ProwJob{
+		Spec: prowapi.ProwJobSpec{Type: prowapi.PostsubmitJob, Refs: &prowapi.Refs{Org: "metrics", Repo: "repo"}},
+		Status: prowapi.ProwJobStatus{
+			StartTime:      *at(0),
+			PendingTime:    at(30),
+			CompletionTime: at(630),
+			State:          prowapi.SuccessState,
+		},
+	}
+	labels := []string{"metrics", "repo", "postsubmit", prowapi.DefaultClusterAlias}
+
+	ObserveProwJobScheduled(pj)
+	if count, sum := histogramSamples(t, timeToSchedule, labels...); count != 1 || sum != 30 {
+		t.Errorf("expected one time to schedule of 30s, got %d summing to %v", count, sum)
+	}
+
+	ObserveProwJobCompleted(pj)
+	if count, sum := histogramSamples(t, runDuration, append(labels, "success")...); count != 1 || sum != 600 {
+		t.Errorf("expected one run duration of 600s, got %d summing to %v", count, sum)
+	}
+
+	pod := coreapi.Pod{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: *at(30)},
+		Status: coreapi.PodStatus{ContainerStatuses: []coreapi.ContainerStatus{
+			{State: coreapi.ContainerState{Terminated: &coreapi.ContainerStateTerminated{StartedAt: *at(50)}}},
+			{State: coreapi.ContainerState{Running: &coreapi.ContainerStateRunning{StartedAt: *at(45)}}},
+		}},
+	}
+	ObservePodPending(pj, pod)
+	if count, sum := histogramSamples(t, timeInPending, labels...); count != 1 || sum != 15 {
+		t.Errorf("expected one pod pending time of 15s, got %d summing to %v", count, sum)
+	}
+
+	// jobs that never started executing are not observed
+	pj.Status.PendingTime = nil
+	ObserveProwJobCompleted(pj)
+	if count, _ := histogramSamples(t, runDuration, append(labels, "success")...); count != 1 {
+		t.Errorf("expected job without pending time to be ignored, got %d samples", count)
+	}
+}
+
+func TestProwJobStateCollector(t *testing.T) {
+	pjs := []*prowapi.ProwJob{
+		{Spec: prowapi.ProwJobSpec{Type: prowapi.PresubmitJob, Refs: &prowapi.Refs{Org: "org", Repo: "repo"}}, Status: prowapi.ProwJobStatus{State: prowapi.PendingState}},
+		{Spec: prowapi.ProwJobSpec{Type: prowapi.PresubmitJob, Refs: &prowapi.Refs{Org: "org", Repo: "repo"}}, Status: prowapi.ProwJobStatus{State: prowapi.PendingState}},
+		{Spec: prowapi.ProwJobSpec{Type: prowapi.PeriodicJob, Cluster: "trusted"}, Status: prowapi.ProwJobStatus{State: prowapi.FailureState}},
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewProwJobStateCollector(func() ([]*prowapi.ProwJob, error) { return pjs, nil }))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	var actual []string
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			var labels []string
+			for _, label := range metric.Label {
+				labels = append(labels, label.GetName()+"="+label.GetValue())
+			}
+			actual = append(actual, fmt.Sprintf("%s %v", strings.Join(labels, ","), metric.Gauge.GetValue()))
+		}
+	}
+	sort.Strings(actual)
+	expected := []string{
+		"cluster=default,org=org,repo=repo,state=pending,type=presubmit 2",
+		"cluster=trusted,org=,repo=,state=failure,type=periodic 1",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected metrics\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
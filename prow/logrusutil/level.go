@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logrusutil
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// levels tracks the configured log level and whether it was overridden
+// at runtime, so that reloading the configuration does not undo an
+// override made while debugging
+var levels = struct {
+	sync.Mutex
+	configured logrus.Level
+	overridden bool
+}{configured: logrus.InfoLevel}
+
+// SetConfiguredLevel sets the log level from configuration. It only takes
+// effect immediately if the level is not overridden at runtime.
+func SetConfiguredLevel(level logrus.Level) {
+	levels.Lock()
+	defer levels.Unlock()
+	levels.configured = level
+	if !levels.overridden {
+		logrus.SetLevel(level)
+	}
+}
+
+// LevelHandler serves the log level of the component. A GET returns the
+// current level, a PUT or POST with a level parameter overrides it until
+// a DELETE restores the configured level.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		levels.Lock()
+		defer levels.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			level, err := logrus.ParseLevel(r.FormValue("level"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			logrus.WithFields(logrus.Fields{"from": logrus.GetLevel(), "to": level}).Info("Overriding log level.")
+			logrus.SetLevel(level)
+			levels.overridden = true
+		case http.MethodDelete:
+			logrus.WithField("level", levels.configured).Info("Restoring configured log level.")
+			logrus.SetLevel(levels.configured)
+			levels.overridden = false
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			http.Error(w, "405 Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprintf(w, "%s\n", logrus.GetLevel())
+	})
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logrusutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLevelHandler(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+	handler := LevelHandler()
+	SetConfiguredLevel(logrus.InfoLevel)
+
+	testCases := []struct {
+		name       string
+		method     string
+		url        string
+		configured logrus.Level
+		code       int
+		expected   logrus.Level
+	}{
+		{
+			name:     "get the configured level",
+			method:   http.MethodGet,
+			url:      "/log-level",
+			code:     http.StatusOK,
+			expected: logrus.InfoLevel,
+		},
+		{
+			name:     "override the level",
+			method:   http.MethodPut,
+			url:      "/log-level?level=debug",
+			code:     http.StatusOK,
+			expected: logrus.DebugLevel,
+		},
+		{
+			name:       "reloading config keeps the override",
+			method:     http.MethodGet,
+			url:        "/log-level",
+			configured: logrus.WarnLevel,
+			code:       http.StatusOK,
+			expected:   logrus.DebugLevel,
+		},
+		{
+			name:     "invalid level is rejected",
+			method:   http.MethodPost,
+			url:      "/log-level?level=verbose",
+			code:     http.StatusBadRequest,
+			expected: logrus.DebugLevel,
+		},
+		{
+			name:     "restore the configured level",
+			method:   http.MethodDelete,
+			url:      "/log-level",
+			code:     http.StatusOK,
+			expected: logrus.WarnLevel,
+		},
+		{
+			name:     "other methods are not allowed",
+			method:   http.MethodPatch,
+			url:      "/log-level",
+			code:     http.StatusMethodNotAllowed,
+			expected: logrus.WarnLevel,
+		},
+	}
+	for _, tc := range testCases {
+		if tc.configured != 0 {
+			SetConfiguredLevel(tc.configured)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.url, nil))
+		if rr.Code != tc.code {
+			t.Errorf("%s: expected code %d, got %d", tc.name, tc.code, rr.Code)
+		}
+		if actual := logrus.GetLevel(); actual != tc.expected {
+			t.Errorf("%s: expected level %s, got %s", tc.name, tc.expected, actual)
+		}
+		if tc.code == http.StatusOK && strings.TrimSpace(rr.Body.String()) != tc.expected.String() {
+			t.Errorf("%s: expected response %q, got %q", tc.name, tc.expected, rr.Body.String())
+		}
+	}
+}
@@ -18,9 +18,23 @@ limitations under the License.
 package logrusutil
 
 import (
+	"os"
+
 	"github.com/sirupsen/logrus"
 )
 
+// FormatEnv selects the format logs are written in, either "json" (the
+// default) or "text"
+const FormatEnv = "PROW_LOG_FORMAT"
+
+// NewFormatter returns the formatter for the format selected with FormatEnv
+func NewFormatter() logrus.Formatter {
+	if os.Getenv(FormatEnv) == "text" {
+		return &logrus.TextFormatter{FullTimestamp: true}
+	}
+	return &logrus.JSONFormatter{}
+}
+
 // DefaultFieldsFormatter wraps another logrus.Formatter, injecting
 // DefaultFields into each Format() call, existing fields are preserved
 // if they have the same key
@@ -30,7 +44,8 @@ type DefaultFieldsFormatter struct {
 }
 
 // NewDefaultFieldsFormatter returns a DefaultFieldsFormatter,
-// if wrappedFormatter is nil &logrus.JSONFormatter{} will be used instead
+// if wrappedFormatter is nil the formatter selected with FormatEnv will be
+// used instead
 func NewDefaultFieldsFormatter(
 	wrappedFormatter logrus.Formatter, defaultFields logrus.Fields,
 ) *DefaultFieldsFormatter {
@@ -39,7 +54,7 @@ func NewDefaultFieldsFormatter(
 		DefaultFields:    defaultFields,
 	}
 	if res.WrappedFormatter == nil {
-		res.WrappedFormatter = &logrus.JSONFormatter{}
+		res.WrappedFormatter = NewFormatter()
 	}
 	return res
 }
@@ -17,12 +17,14 @@ limitations under the License.
 package plank
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 	"k8s.io/api/core/v1"
 	coreapi "k8s.io/api/core/v1"
 
@@ -34,6 +36,7 @@ import (
 	"k8s.io/test-infra/prow/kube"
 	"k8s.io/test-infra/prow/pjutil"
 	"k8s.io/test-infra/prow/pod-utils/decorate"
+	"k8s.io/test-infra/prow/tracing"
 )
 
 type kubeClient interface {
@@ -378,6 +381,7 @@ func (c *Controller) syncPendingJob(pj prowapi.ProwJob, pm map[string]coreapi.Po
 			pj.SetComplete()
 			pj.Status.State = prowapi.SuccessState
 			pj.Status.Description = "Job succeeded."
+			kube.ObservePodPending(pj, pod)
 
 		case coreapi.PodFailed:
 			if pod.Status.Reason == kube.Evicted {
@@ -402,6 +406,7 @@ func (c *Controller) syncPendingJob(pj prowapi.ProwJob, pm map[string]coreapi.Po
 			pj.SetComplete()
 			pj.Status.State = prowapi.FailureState
 			pj.Status.Description = "Job failed."
+			kube.ObservePodPending(pj, pod)
 
 		case coreapi.PodPending:
 			maxPodPending := c.config().Plank.PodPendingTimeout
@@ -481,10 +486,11 @@ func (c *Controller) syncTriggeredJob(pj prowapi.ProwJob, pm map[string]coreapi.
 	if pj.Status.State == prowapi.TriggeredState {
 		// BuildID needs to be set before we execute the job url template.
 		pj.Status.BuildID = id
-		pj.Status.State = prowapi.PendingState
+		pj.SetPending()
 		pj.Status.PodName = pn
 		pj.Status.Description = "Job triggered."
 		pj.Status.URL = pjutil.JobURL(c.config().Plank, pj, c.log)
+		kube.ObserveProwJobScheduled(pj)
 	}
 	reports <- pj
 	if prevState != pj.Status.State {
@@ -508,6 +514,12 @@ func (c *Controller) startPod(pj prowapi.ProwJob) (string, string, error) {
 	if err != nil {
 		return "", "", err
 	}
+	if parent, traced := tracing.FromAnnotations(pj.Annotations); traced {
+		_, span := trace.StartSpanWithRemoteParent(context.Background(), "start pod", parent)
+		span.AddAttributes(trace.StringAttribute("job", pj.Spec.Job), trace.StringAttribute("cluster", pj.ClusterAlias()))
+		defer span.End()
+		injectTraceContext(pod, span.SpanContext(), c.config().Tracing.ZipkinEndpoint)
+	}
 
 	client, ok := c.pkcs[pj.ClusterAlias()]
 	if !ok {
@@ -533,3 +545,20 @@ func getPodBuildID(pod *coreapi.Pod) string {
 	logrus.Warningf("BUILD_ID was not found in pod %q: streaming logs from deck will not work", pod.ObjectMeta.Name)
 	return ""
 }
+
+// injectTraceContext lets the pod utilities continue the trace of the job
+func injectTraceContext(pod *coreapi.Pod, sc trace.SpanContext, endpoint string) {
+	if !sc.IsSampled() || endpoint == "" {
+		return
+	}
+	env := []coreapi.EnvVar{
+		{Name: tracing.ContextEnv, Value: tracing.Encode(sc)},
+		{Name: tracing.EndpointEnv, Value: endpoint},
+	}
+	for i := range pod.Spec.InitContainers {
+		pod.Spec.InitContainers[i].Env = append(pod.Spec.InitContainers[i].Env, env...)
+	}
+	for i := range pod.Spec.Containers {
+		pod.Spec.Containers[i].Env = append(pod.Spec.Containers[i].Env, env...)
+	}
+}
@@ -43,8 +43,9 @@ func handleGenericComment(c Client, trigger plugins.Trigger, gc github.GenericCo
 	if gc.Action != github.GenericCommentActionCreated || !gc.IsPR || gc.IssueState != "open" {
 		return nil
 	}
-	// Skip comments not germane to this plugin
-	if !retestRe.MatchString(gc.Body) && !okToTestRe.MatchString(gc.Body) && !testAllRe.MatchString(gc.Body) {
+	// Skip comments not germane to this plugin. Jobs configured in the repo
+	// itself are only known once the PR is fetched, so we cannot skip early.
+	if !retestRe.MatchString(gc.Body) && !okToTestRe.MatchString(gc.Body) && !testAllRe.MatchString(gc.Body) && !c.Config.InRepoConfigEnabled(gc.Repo.FullName) {
 		matched := false
 		for _, presubmit := range c.Config.Presubmits[gc.Repo.FullName] {
 			matched = matched || presubmit.TriggerMatches(gc.Body)
@@ -113,7 +114,11 @@ func handleGenericComment(c Client, trigger plugins.Trigger, gc github.GenericCo
 		}
 	}
 
-	toTest, toSkip, err := FilterPresubmits(HonorOkToTest(trigger), c.GitHubClient, gc.Body, pr, c.Config.Presubmits[gc.Repo.FullName], c.Logger)
+	presubmits, err := c.Config.GetPresubmits(c.GitClient, gc.Repo.FullName, pr.Base.SHA, pr.Head.SHA)
+	if err != nil {
+		return fmt.Errorf("failed to get presubmits: %v", err)
+	}
+	toTest, toSkip, err := FilterPresubmits(HonorOkToTest(trigger), c.GitHubClient, gc.Body, pr, presubmits, c.Logger)
 	if err != nil {
 		return err
 	}
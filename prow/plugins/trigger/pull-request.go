@@ -218,7 +218,11 @@ func TrustedPullRequest(ghc githubClient, trigger plugins.Trigger, author, org,
 
 // buildAll ensures that all builds that should run and will be required are built
 func buildAll(c Client, pr *github.PullRequest, eventGUID string, elideSkippedContexts bool) error {
-	toTest, toSkip, err := filterPresubmits(testAllFilter(), c.GitHubClient, pr, c.Config.Presubmits[pr.Base.Repo.FullName], c.Logger)
+	presubmits, err := c.Config.GetPresubmits(c.GitClient, pr.Base.Repo.FullName, pr.Base.SHA, pr.Head.SHA)
+	if err != nil {
+		return fmt.Errorf("failed to get presubmits: %v", err)
+	}
+	toTest, toSkip, err := filterPresubmits(testAllFilter(), c.GitHubClient, pr, presubmits, c.Logger)
 	if err != nil {
 		return err
 	}
@@ -17,6 +17,8 @@ limitations under the License.
 package trigger
 
 import (
+	"fmt"
+
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/github"
@@ -60,7 +62,11 @@ func handlePE(c Client, pe github.PushEvent) error {
 		// we should not trigger jobs for a branch deletion
 		return nil
 	}
-	for _, j := range c.Config.Postsubmits[pe.Repo.FullName] {
+	postsubmits, err := c.Config.GetPostsubmits(c.GitClient, pe.Repo.FullName, pe.After)
+	if err != nil {
+		return fmt.Errorf("failed to get postsubmits: %v", err)
+	}
+	for _, j := range postsubmits {
 		if shouldRun, err := j.ShouldRun(pe.Branch(), listPushEventChanges(pe)); err != nil {
 			return err
 		} else if !shouldRun {
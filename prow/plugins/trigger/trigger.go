@@ -25,6 +25,7 @@ import (
 	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
 	"k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/prow/errorutil"
+	"k8s.io/test-infra/prow/git"
 	"k8s.io/test-infra/prow/github"
 	"k8s.io/test-infra/prow/pjutil"
 	"k8s.io/test-infra/prow/pluginhelp"
@@ -118,6 +119,7 @@ type prowJobClient interface {
 type Client struct {
 	GitHubClient  githubClient
 	ProwJobClient prowJobClient
+	GitClient     *git.Client
 	Config        *config.Config
 	Logger        *logrus.Entry
 }
@@ -132,6 +134,7 @@ func getClient(pc plugins.Agent) Client {
 		GitHubClient:  pc.GitHubClient,
 		Config:        pc.Config,
 		ProwJobClient: pc.ProwJobClient,
+		GitClient:     pc.GitClient,
 		Logger:        pc.Logger,
 	}
 }
@@ -0,0 +1,165 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing traces the handling of events across Prow components.
+// A trace is started when hook receives a webhook and its context is
+// propagated to the ProwJobs created for the event with an annotation,
+// and from there to the pods of the jobs with environment variables.
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	// Annotation holds the trace context on ProwJobs
+	Annotation = "prow.k8s.io/trace-context"
+	// ContextEnv holds the trace context in the pods of ProwJobs
+	ContextEnv = "PROW_TRACE_CONTEXT"
+	// EndpointEnv holds the endpoint spans are sent to in the pods of ProwJobs
+	EndpointEnv = "PROW_TRACE_ENDPOINT"
+)
+
+// Config configures tracing for all Prow components.
+type Config struct {
+	// ZipkinEndpoint is the URL of the Zipkin v2 spans API that spans are
+	// sent to, e.g. http://otel-collector:9411/api/v2/spans. Tracing is
+	// disabled if it is unset.
+	ZipkinEndpoint string `json:"zipkin_endpoint,omitempty"`
+	// SampleFraction is the fraction of webhooks that are traced.
+	SampleFraction float64 `json:"sample_fraction,omitempty"`
+}
+
+// Validate ensures the tracing config is valid.
+func (c Config) Validate() error {
+	if c.SampleFraction < 0 || c.SampleFraction > 1 {
+		return fmt.Errorf("sample_fraction must be between 0 and 1, got %v", c.SampleFraction)
+	}
+	return nil
+}
+
+// Start sends the spans of the component to the configured endpoint. The
+// config is consulted for every trace, so changes apply immediately.
+func Start(component string, config func() Config) {
+	trace.ApplyConfig(trace.Config{DefaultSampler: func(p trace.SamplingParameters) trace.SamplingDecision {
+		if p.ParentContext.IsSampled() {
+			return trace.SamplingDecision{Sample: true}
+		}
+		c := config()
+		if c.ZipkinEndpoint == "" {
+			return trace.SamplingDecision{Sample: false}
+		}
+		return trace.ProbabilitySampler(c.SampleFraction)(p)
+	}})
+	trace.RegisterExporter(newZipkinExporter(component, func() string { return config().ZipkinEndpoint }))
+}
+
+// StartFromEnvironment sends the spans of a pod utility to the endpoint set
+// in its environment and returns the trace context of its ProwJob. Spans are
+// only recorded for jobs that were traced.
+func StartFromEnvironment(component string) (trace.SpanContext, bool) {
+	trace.ApplyConfig(trace.Config{DefaultSampler: func(p trace.SamplingParameters) trace.SamplingDecision {
+		return trace.SamplingDecision{Sample: p.ParentContext.IsSampled()}
+	}})
+	parent, ok := Decode(os.Getenv(ContextEnv))
+	endpoint := os.Getenv(EndpointEnv)
+	if !ok || endpoint == "" {
+		return trace.SpanContext{}, false
+	}
+	trace.RegisterExporter(newZipkinExporter(component, func() string { return endpoint }))
+	return parent, true
+}
+
+// Encode formats the trace context as a W3C traceparent header value
+func Encode(sc trace.SpanContext) string {
+	flags := "00"
+	if sc.IsSampled() {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// Decode parses a trace context encoded by Encode
+func Decode(value string) (trace.SpanContext, bool) {
+	var sc trace.SpanContext
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return sc, false
+	}
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != len(sc.TraceID) {
+		return sc, false
+	}
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != len(sc.SpanID) {
+		return sc, false
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	switch parts[3] {
+	case "01":
+		sc.TraceOptions = trace.TraceOptions(1)
+	case "00":
+	default:
+		return sc, false
+	}
+	return sc, true
+}
+
+// FromAnnotations returns the trace context of a traced ProwJob
+func FromAnnotations(annotations map[string]string) (trace.SpanContext, bool) {
+	value, ok := annotations[Annotation]
+	if !ok {
+		return trace.SpanContext{}, false
+	}
+	return Decode(value)
+}
+
+// SetAnnotation records the trace context on a ProwJob if the trace is sampled
+func SetAnnotation(annotations map[string]string, sc trace.SpanContext) map[string]string {
+	if !sc.IsSampled() {
+		return annotations
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[Annotation] = Encode(sc)
+	return annotations
+}
+
+// StartPodUtility starts the span of a pod utility if its job is traced.
+// The returned function ends the span, marking it as failed if the error is
+// not nil, and sends it. It must be called before the utility exits.
+func StartPodUtility(component string) func(err error) {
+	parent, traced := StartFromEnvironment(component)
+	if !traced {
+		return func(error) {}
+	}
+	_, span := trace.StartSpanWithRemoteParent(context.Background(), component, parent)
+	return func(err error) {
+		if err != nil {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+		}
+		span.End()
+		Flush()
+	}
+}
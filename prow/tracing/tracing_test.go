@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	sampled := trace.SpanContext{
+		TraceID:      trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:       trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceOptions: trace.TraceOptions(1),
+	}
+	encoded := Encode(sampled)
+	if expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; encoded != expected {
+		t.Errorf("expected %q, got %q", expected, encoded)
+	}
+	if decoded, ok := Decode(encoded); !ok || !reflect.DeepEqual(decoded, sampled) {
+		t.Errorf("expected %q to decode to %v, got %v", encoded, sampled, decoded)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-02",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+	} {
+		if _, ok := Decode(invalid); ok {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestSetAnnotation(t *testing.T) {
+	sc := trace.SpanContext{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}}
+	if annotations := SetAnnotation(nil, sc); annotations != nil {
+		t.Errorf("expected unsampled trace not to be recorded, got %v", annotations)
+	}
+	sc.TraceOptions = trace.TraceOptions(1)
+	annotations := SetAnnotation(map[string]string{"other": "value"}, sc)
+	if decoded, ok := FromAnnotations(annotations); !ok || decoded != sc {
+		t.Errorf("expected trace context to be recorded, got %v", annotations)
+	}
+	if annotations["other"] != "value" {
+		t.Errorf("expected other annotations to be kept, got %v", annotations)
+	}
+}
+
+func TestZipkinExporter(t *testing.T) {
+	received := make(chan []zipkinSpan, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var spans []zipkinSpan
+		if err := json.NewDecoder(r.Body).Decode(&spans); err != nil {
+			t.Errorf("failed to decode spans: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		received <- spans
+	}))
+	defer server.Close()
+
+	start := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	exporter := newZipkinExporter("hook", func() string { return server.URL })
+	exporter.ExportSpan(&trace.SpanData{
+		SpanContext:  trace.SpanContext{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}},
+		ParentSpanID: trace.SpanID{3},
+		SpanKind:     trace.SpanKindServer,
+		Name:         "webhook",
+		StartTime:    start,
+		EndTime:      start.Add(1500 * time.Microsecond),
+		Attributes:   map[string]interface{}{"event-type": "issue_comment"},
+	})
+	Flush()
+
+	expected := []zipkinSpan{{
+		TraceID:       "01000000000000000000000000000000",
+		ID:            "0200000000000000",
+		ParentID:      "0300000000000000",
+		Name:          "webhook",
+		Kind:          "SERVER",
+		Timestamp:     start.UnixNano() / 1000,
+		Duration:      1500,
+		LocalEndpoint: zipkinEndpoint{ServiceName: "hook"},
+		Tags:          map[string]string{"event-type": "issue_comment"},
+	}}
+	select {
+	case spans := <-received:
+		if !reflect.DeepEqual(spans, expected) {
+			t.Errorf("expected spans %v, got %v", expected, spans)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for spans")
+	}
+}
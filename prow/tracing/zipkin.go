@@ -0,0 +1,164 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+)
+
+// zipkinSpan is a span in the Zipkin v2 JSON format, which is accepted
+// by Zipkin, Jaeger and the OpenTelemetry collector
+type zipkinSpan struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Kind          string            `json:"kind,omitempty"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint zipkinEndpoint    `json:"localEndpoint"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+func toZipkin(component string, data *trace.SpanData) zipkinSpan {
+	span := zipkinSpan{
+		TraceID:       hex.EncodeToString(data.TraceID[:]),
+		ID:            hex.EncodeToString(data.SpanID[:]),
+		Name:          data.Name,
+		Timestamp:     data.StartTime.UnixNano() / int64(time.Microsecond),
+		Duration:      int64(data.EndTime.Sub(data.StartTime) / time.Microsecond),
+		LocalEndpoint: zipkinEndpoint{ServiceName: component},
+	}
+	if data.ParentSpanID != (trace.SpanID{}) {
+		span.ParentID = hex.EncodeToString(data.ParentSpanID[:])
+	}
+	switch data.SpanKind {
+	case trace.SpanKindServer:
+		span.Kind = "SERVER"
+	case trace.SpanKindClient:
+		span.Kind = "CLIENT"
+	}
+	if len(data.Attributes) > 0 || data.Code != 0 {
+		span.Tags = map[string]string{}
+		for key, value := range data.Attributes {
+			span.Tags[key] = fmt.Sprint(value)
+		}
+		if data.Code != 0 {
+			span.Tags["error"] = data.Message
+		}
+	}
+	return span
+}
+
+// zipkinExporter sends spans to a Zipkin v2 spans API in batches
+type zipkinExporter struct {
+	component string
+	endpoint  func() string
+	client    *http.Client
+
+	lock    sync.Mutex
+	pending []zipkinSpan
+}
+
+// batchInterval is how often batches of spans are sent
+const batchInterval = 5 * time.Second
+
+// exporters are all registered exporters, to flush on exit
+var (
+	exportersLock sync.Mutex
+	exporters     []*zipkinExporter
+)
+
+func newZipkinExporter(component string, endpoint func() string) *zipkinExporter {
+	e := &zipkinExporter{
+		component: component,
+		endpoint:  endpoint,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+	exportersLock.Lock()
+	exporters = append(exporters, e)
+	exportersLock.Unlock()
+	go func() {
+		for range time.Tick(batchInterval) {
+			e.flush()
+		}
+	}()
+	return e
+}
+
+// ExportSpan queues the span to be sent with the next batch
+func (e *zipkinExporter) ExportSpan(data *trace.SpanData) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.pending = append(e.pending, toZipkin(e.component, data))
+}
+
+func (e *zipkinExporter) flush() {
+	e.lock.Lock()
+	spans := e.pending
+	e.pending = nil
+	e.lock.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	if err := e.send(spans); err != nil {
+		logrus.WithError(err).WithField("spans", len(spans)).Warn("Failed to send spans.")
+	}
+}
+
+func (e *zipkinExporter) send(spans []zipkinSpan) error {
+	endpoint := e.endpoint()
+	if endpoint == "" {
+		return nil
+	}
+	body, err := json.Marshal(spans)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("got status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Flush sends all spans that were not sent yet. Short lived processes
+// should call this before exiting.
+func Flush() {
+	exportersLock.Lock()
+	defer exportersLock.Unlock()
+	for _, e := range exporters {
+		e.flush()
+	}
+}
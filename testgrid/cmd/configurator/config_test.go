@@ -72,6 +72,17 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 
+	// jobs may configure their testgrid entries with annotations
+	prowConfig, err := prow_config.Load("../../../prow/config.yaml", "../../../config/jobs")
+	if err != nil {
+		fmt.Printf("Could not load prow configs: %v\n", err)
+		os.Exit(1)
+	}
+	if err := c.applyProwjobAnnotations(prowConfig); err != nil {
+		fmt.Printf("Could not apply prow job annotations: %v\n", err)
+		os.Exit(1)
+	}
+
 	cfg, err = c.Raw()
 	if err != nil {
 		fmt.Printf("Error validating config: %v", err)
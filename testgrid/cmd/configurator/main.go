@@ -28,6 +28,7 @@ import (
 	"strings"
 	"time"
 
+	prow_config "k8s.io/test-infra/prow/config"
 	"k8s.io/test-infra/testgrid/util/gcs"
 
 	"cloud.google.com/go/storage"
@@ -50,6 +51,8 @@ type options struct {
 	oneshot            bool
 	output             string
 	printText          bool
+	prowConfig         string
+	prowJobConfig      string
 	validateConfigFile bool
 	worldReadable      bool
 }
@@ -60,6 +63,8 @@ func gatherOptions() (options, error) {
 	flag.BoolVar(&o.oneshot, "oneshot", false, "Write proto once and exit instead of monitoring --yaml files for changes")
 	flag.StringVar(&o.output, "output", "", "write proto to gs://bucket/obj or /local/path")
 	flag.BoolVar(&o.printText, "print-text", false, "print generated proto in text format to stdout")
+	flag.StringVar(&o.prowConfig, "prow-config", "", "path to the prow config, adds test groups and dashboard tabs for jobs with testgrid-* annotations if set")
+	flag.StringVar(&o.prowJobConfig, "prow-job-config", "", "path to the prow job configs, requires --prow-config")
 	flag.BoolVar(&o.validateConfigFile, "validate-config-file", false, "validate that the given config files are syntactically correct and exit (proto is not written anywhere)")
 	flag.BoolVar(&o.worldReadable, "world-readable", false, "when uploading the proto to GCS, makes it world readable. Has no effect on writing to the local filesystem.")
 	flag.Var(&o.inputs, "yaml", "comma-separated list of input YAML files")
@@ -74,6 +79,9 @@ func gatherOptions() (options, error) {
 	if o.validateConfigFile && o.output != "" {
 		return o, errors.New("--validate-config-file doesn't write the proto anywhere")
 	}
+	if o.prowJobConfig != "" && o.prowConfig == "" {
+		return o, errors.New("--prow-job-config requires --prow-config")
+	}
 	return o, nil
 }
 
@@ -121,7 +129,7 @@ func announceChanges(ctx context.Context, paths []string, channel chan []string)
 	}
 }
 
-func readConfig(paths []string) (*Config, error) {
+func readConfig(paths []string, prowConfig, prowJobConfig string) (*Config, error) {
 	var c Config
 	for _, file := range paths {
 		b, err := ioutil.ReadFile(file)
@@ -132,6 +140,15 @@ func readConfig(paths []string) (*Config, error) {
 			return nil, fmt.Errorf("failed to merge %s into config: %v", file, err)
 		}
 	}
+	if prowConfig != "" {
+		pc, err := prow_config.Load(prowConfig, prowJobConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load prow config: %v", err)
+		}
+		if err := c.applyProwjobAnnotations(pc); err != nil {
+			return nil, fmt.Errorf("failed to apply prow job annotations: %v", err)
+		}
+	}
 	return &c, nil
 }
 
@@ -152,7 +169,7 @@ func write(ctx context.Context, client *storage.Client, path string, bytes []byt
 
 func doOneshot(ctx context.Context, client *storage.Client, opt options) error {
 	// Ignore what changed for now and just recompute everything
-	c, err := readConfig(opt.inputs)
+	c, err := readConfig(opt.inputs, opt.prowConfig, opt.prowJobConfig)
 	if err != nil {
 		return fmt.Errorf("could not read config: %v", err)
 	}
@@ -212,7 +229,14 @@ func main() {
 	// Service mode, monitor input files for changes
 	channel := make(chan []string)
 	// Monitor files for changes
-	go announceChanges(ctx, opt.inputs, channel)
+	watched := append([]string{}, opt.inputs...)
+	if opt.prowConfig != "" {
+		watched = append(watched, opt.prowConfig)
+	}
+	if opt.prowJobConfig != "" {
+		watched = append(watched, opt.prowJobConfig)
+	}
+	go announceChanges(ctx, watched, channel)
 
 	// Wait for changed files
 	for changes := range channel {
@@ -0,0 +1,170 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	prow_config "k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/testgrid/config"
+)
+
+// defaultBucket holds the results of undecorated jobs
+const defaultBucket = "kubernetes-jenkins"
+
+// gcsPrefix returns where the results of the job are uploaded to
+func gcsPrefix(job prow_config.JobBase, presubmit bool) string {
+	bucket, pathPrefix := defaultBucket, ""
+	if job.DecorationConfig != nil && job.DecorationConfig.GCSConfiguration != nil {
+		if gcs := job.DecorationConfig.GCSConfiguration; gcs.Bucket != "" {
+			bucket, pathPrefix = gcs.Bucket, gcs.PathPrefix
+		}
+	}
+	dir := "logs"
+	if presubmit {
+		dir = "pr-logs/directory"
+	}
+	return path.Join(bucket, pathPrefix, dir, job.Name)
+}
+
+// applyProwjobAnnotations adds a test group and dashboard tabs for every
+// prow job with a testgrid-dashboards annotation. Test groups and tabs
+// configured in YAML take precedence over the annotations.
+func (c *Config) applyProwjobAnnotations(prowConfig *prow_config.Config) error {
+	if c.config == nil {
+		c.config = &config.Configuration{}
+	}
+	type annotatedJob struct {
+		job       prow_config.JobBase
+		presubmit bool
+	}
+	var jobs []annotatedJob
+	for _, job := range prowConfig.AllPresubmits(nil) {
+		jobs = append(jobs, annotatedJob{job: job.JobBase, presubmit: true})
+	}
+	for _, job := range prowConfig.AllPostsubmits(nil) {
+		jobs = append(jobs, annotatedJob{job: job.JobBase})
+	}
+	for _, job := range prowConfig.AllPeriodics() {
+		jobs = append(jobs, annotatedJob{job: job.JobBase})
+	}
+	// jobs come from maps, sort them for a stable configuration
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].job.Name < jobs[j].job.Name
+	})
+
+	for _, j := range jobs {
+		if err := c.applyAnnotations(j.job, j.presubmit); err != nil {
+			return fmt.Errorf("job %s: %v", j.job.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) applyAnnotations(job prow_config.JobBase, presubmit bool) error {
+	dashboards := prow_config.TestgridDashboards(job.Annotations)
+	if len(dashboards) == 0 {
+		return nil
+	}
+
+	if c.testGroup(job.Name) == nil {
+		testGroup := &config.TestGroup{
+			Name:      job.Name,
+			GcsPrefix: gcsPrefix(job, presubmit),
+		}
+		ReconcileTestGroup(testGroup, c.defaultTestGroup())
+		c.config.TestGroups = append(c.config.TestGroups, testGroup)
+	}
+
+	tabName := job.Name
+	if name, ok := job.Annotations[prow_config.TestgridTabNameAnnotation]; ok {
+		tabName = strings.TrimSpace(name)
+	}
+	for _, name := range dashboards {
+		dashboard := c.dashboard(name)
+		if dashboard == nil {
+			dashboard = &config.Dashboard{Name: name}
+			c.config.Dashboards = append(c.config.Dashboards, dashboard)
+		}
+		if existing := tab(dashboard, tabName); existing != nil {
+			if existing.TestGroupName != job.Name {
+				return fmt.Errorf("dashboard %s already has a tab %s showing test group %s", name, tabName, existing.TestGroupName)
+			}
+			continue
+		}
+		dashboardTab := &config.DashboardTab{
+			Name:          tabName,
+			TestGroupName: job.Name,
+			Description:   job.Annotations[prow_config.TestgridDescriptionAnnotation],
+		}
+		emails := prow_config.TestgridAlertEmails(job.Annotations)
+		failures := prow_config.TestgridNumFailuresToAlert(job.Annotations)
+		if len(emails) > 0 || failures > 0 {
+			dashboardTab.AlertOptions = &config.DashboardTabAlertOptions{
+				AlertMailToAddresses: strings.Join(emails, ","),
+				NumFailuresToAlert:   int32(failures),
+			}
+		}
+		ReconcileDashboardTab(dashboardTab, c.defaultDashboardTab())
+		dashboard.DashboardTab = append(dashboard.DashboardTab, dashboardTab)
+	}
+	return nil
+}
+
+func (c *Config) testGroup(name string) *config.TestGroup {
+	for _, testGroup := range c.config.TestGroups {
+		if testGroup.Name == name {
+			return testGroup
+		}
+	}
+	return nil
+}
+
+func (c *Config) dashboard(name string) *config.Dashboard {
+	for _, dashboard := range c.config.Dashboards {
+		if dashboard.Name == name {
+			return dashboard
+		}
+	}
+	return nil
+}
+
+func tab(dashboard *config.Dashboard, name string) *config.DashboardTab {
+	for _, dashboardTab := range dashboard.DashboardTab {
+		if dashboardTab.Name == name {
+			return dashboardTab
+		}
+	}
+	return nil
+}
+
+func (c *Config) defaultTestGroup() *config.TestGroup {
+	if c.defaultConfig == nil || c.defaultConfig.DefaultTestGroup == nil {
+		return &config.TestGroup{}
+	}
+	return c.defaultConfig.DefaultTestGroup
+}
+
+func (c *Config) defaultDashboardTab() *config.DashboardTab {
+	if c.defaultConfig == nil || c.defaultConfig.DefaultDashboardTab == nil {
+		return &config.DashboardTab{}
+	}
+	return c.defaultConfig.DefaultDashboardTab
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	prowapi "k8s.io/test-infra/prow/apis/prowjobs/v1"
+	prow_config "k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/testgrid/config"
+)
+
+func TestApplyProwjobAnnotations(t *testing.T) {
+	c := Config{
+		config: &config.Configuration{
+			TestGroups: []*config.TestGroup{{Name: "configured", GcsPrefix: "bucket/configured"}},
+			Dashboards: []*config.Dashboard{{
+				Name:         "existing",
+				DashboardTab: []*config.DashboardTab{{Name: "configured", TestGroupName: "configured"}},
+			}},
+		},
+		defaultConfig: &config.DefaultConfiguration{
+			DefaultTestGroup:    &config.TestGroup{DaysOfResults: 14},
+			DefaultDashboardTab: &config.DashboardTab{NumColumnsRecent: 10},
+		},
+	}
+	prowConfig := &prow_config.Config{JobConfig: prow_config.JobConfig{
+		Presubmits: map[string][]prow_config.Presubmit{
+			"org/repo": {{JobBase: prow_config.JobBase{
+				Name: "pull-unit",
+				Annotations: map[string]string{
+					prow_config.TestgridDashboardsAnnotation:         "existing, new",
+					prow_config.TestgridTabNameAnnotation:            "unit",
+					prow_config.TestgridAlertEmailAnnotation:         "a@example.com, b@example.com",
+					prow_config.TestgridNumFailuresToAlertAnnotation: "2",
+				},
+			}}},
+		},
+		Periodics: []prow_config.Periodic{
+			{JobBase: prow_config.JobBase{
+				Name:        "configured",
+				Annotations: map[string]string{prow_config.TestgridDashboardsAnnotation: "existing"},
+			}},
+			{JobBase: prow_config.JobBase{
+				Name:        "ci-decorated",
+				Annotations: map[string]string{prow_config.TestgridDashboardsAnnotation: "new"},
+				UtilityConfig: prow_config.UtilityConfig{DecorationConfig: &prowapi.DecorationConfig{
+					GCSConfiguration: &prowapi.GCSConfiguration{Bucket: "other-bucket", PathPrefix: "prefix"},
+				}},
+			}},
+			{JobBase: prow_config.JobBase{Name: "ci-not-annotated"}},
+		},
+	}}
+
+	if err := c.applyProwjobAnnotations(prowConfig); err != nil {
+		t.Fatalf("failed to apply annotations: %v", err)
+	}
+
+	expectedPrefixes := map[string]string{
+		"configured":   "bucket/configured",
+		"pull-unit":    "kubernetes-jenkins/pr-logs/directory/pull-unit",
+		"ci-decorated": "other-bucket/prefix/logs/ci-decorated",
+	}
+	if len(c.config.TestGroups) != len(expectedPrefixes) {
+		t.Errorf("expected test groups %v, got %v", expectedPrefixes, c.config.TestGroups)
+	}
+	for name, prefix := range expectedPrefixes {
+		testGroup := c.testGroup(name)
+		if testGroup == nil {
+			t.Errorf("expected test group %s", name)
+			continue
+		}
+		if testGroup.GcsPrefix != prefix {
+			t.Errorf("expected test group %s to read %s, got %s", name, prefix, testGroup.GcsPrefix)
+		}
+	}
+	if days := c.testGroup("pull-unit").DaysOfResults; days != 14 {
+		t.Errorf("expected generated test group to get defaults, got %d days of results", days)
+	}
+
+	existing, created := c.dashboard("existing"), c.dashboard("new")
+	if existing == nil || created == nil {
+		t.Fatalf("expected dashboards existing and new, got %v", c.config.Dashboards)
+	}
+	if len(existing.DashboardTab) != 2 {
+		t.Errorf("expected configured tab to be kept and unit tab to be added, got %v", existing.DashboardTab)
+	}
+	unit := tab(created, "unit")
+	if unit == nil || unit.TestGroupName != "pull-unit" {
+		t.Fatalf("expected tab unit showing pull-unit, got %v", unit)
+	}
+	if unit.AlertOptions == nil || unit.AlertOptions.AlertMailToAddresses != "a@example.com,b@example.com" || unit.AlertOptions.NumFailuresToAlert != 2 {
+		t.Errorf("expected alert options from annotations, got %v", unit.AlertOptions)
+	}
+	if unit.NumColumnsRecent != 10 {
+		t.Errorf("expected generated tab to get defaults, got %d recent columns", unit.NumColumnsRecent)
+	}
+	if tab(created, "ci-decorated") == nil {
+		t.Errorf("expected tab named after the job without tab name annotation")
+	}
+}
+
+func TestApplyProwjobAnnotationsConflict(t *testing.T) {
+	c := Config{config: &config.Configuration{
+		Dashboards: []*config.Dashboard{{
+			Name:         "dash",
+			DashboardTab: []*config.DashboardTab{{Name: "unit", TestGroupName: "other"}},
+		}},
+	}}
+	prowConfig := &prow_config.Config{JobConfig: prow_config.JobConfig{
+		Periodics: []prow_config.Periodic{{JobBase: prow_config.JobBase{
+			Name: "ci-unit",
+			Annotations: map[string]string{
+				prow_config.TestgridDashboardsAnnotation: "dash",
+				prow_config.TestgridTabNameAnnotation:    "unit",
+			},
+		}}},
+	}}
+	if err := c.applyProwjobAnnotations(prowConfig); err == nil {
+		t.Error("expected an error for a tab name used by another test group")
+	}
+}